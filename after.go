@@ -0,0 +1,98 @@
+package gofsm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+/**
+定时转换
+*/
+type timeout struct {
+	after    time.Duration
+	transfer *Transition
+}
+
+/**
+定义定时转换：实例在 state 停留 d 后自动转换到 to，事件名为 after(d)
+只在 Instance.Run 中生效，进入 state 时开始计时，状态变化时取消
+每个状态只能有一个定时转换，重复定义时覆盖，action 为 nil 时使用 NoopAction
+*/
+func (sm *StateMachine) After(state State, d time.Duration, to State, action Action) *StateMachine {
+	sm.mutable()
+	state, to = sm.normState(state), sm.normState(to)
+	if action == nil {
+		action = NoopAction
+	}
+	if sm.sg.timeouts == nil {
+		sm.sg.timeouts = map[State]*timeout{}
+	}
+	sm.sg.timeouts[state] = &timeout{after: d, transfer: &Transition{
+		From:   state,
+		Event:  Event(fmt.Sprintf("after(%s)", d)),
+		To:     []State{to},
+		Action: action,
+	}}
+	return sm
+}
+
+/**
+有定时转换的状态，排序后返回
+*/
+func (sg *stateGraph) timeoutStates() []State {
+	var states []State
+	for state := range sg.timeouts {
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i] < states[j] })
+	return states
+}
+
+/**
+执行 state 的定时转换，实例已经离开 state 时不执行并返回 false
+*/
+func (i *Instance) fireTimeout(ctx context.Context, state State) (Event, State, bool, error) {
+	i.mu.Lock()
+	t, ok := i.sm.sg.timeouts[state]
+	if !ok || i.current != state {
+		current := i.current
+		i.mu.Unlock()
+		return None, current, false, nil
+	}
+	to, err := i.fire(ctx, t.transfer.Event, triggerOptions{transfer: t.transfer})
+	complete := i.completion(to, err)
+	i.mu.Unlock()
+	if complete != nil {
+		complete(ctx, to)
+	}
+	return t.transfer.Event, to, true, err
+}
+
+/**
+实例当前状态的定时器，用于定时转换和停留时间告警，状态没有定义时 C 为 nil
+*/
+type stateTimer struct {
+	state State
+	timer *time.Timer
+	C     <-chan time.Time
+	since time.Time // 开始计时的时间
+}
+
+func (i *Instance) armTimer(old *stateTimer) *stateTimer {
+	old.stop()
+	current := i.Current()
+	t, ok := i.sm.sg.timeouts[current]
+	if !ok {
+		return &stateTimer{state: current}
+	}
+	timer := time.NewTimer(t.after)
+	return &stateTimer{state: current, timer: timer, C: timer.C}
+}
+
+func (t *stateTimer) stop() {
+	if t != nil && t.timer != nil {
+		t.timer.Stop()
+	}
+}
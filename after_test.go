@@ -0,0 +1,62 @@
+package gofsm_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestInstance_Run_After(t *testing.T) {
+	newMachine := func() *gofsm.StateMachine {
+		return newOrderMachine().
+			States(gofsm.StatesDef{"new": "", "paid": "", "sent": "", "cancelled": ""}).
+			After("new", 20*time.Millisecond, "cancelled", nil)
+	}
+
+	tests := []struct {
+		name   string
+		events []gofsm.Event
+		want   []string
+	}{
+		{"Timeout", nil, []string{"after(20ms):cancelled"}},
+		{"Cancelled By Event", []gofsm.Event{"pay"}, []string{"pay:paid"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			results := make(chan string, 10)
+			ins := newMachine().NewInstance("new").
+				Observe(func(event gofsm.Event, state gofsm.State, err error) {
+					results <- fmt.Sprintf("%s:%s", event, state)
+				})
+			for _, event := range tt.events {
+				ins.Post(event)
+			}
+			go ins.Run(ctx)
+
+			time.Sleep(60 * time.Millisecond)
+			cancel()
+			var got []string
+			for len(results) > 0 {
+				got = append(got, <-results)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("results = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStateMachine_After_Show(t *testing.T) {
+	got := plantUMLScript(t, newOrderMachine().
+		States(gofsm.StatesDef{"new": "", "paid": "", "sent": "", "cancelled": ""}).
+		After("new", 30*time.Minute, "cancelled", nil))
+	if want := "new --> cancelled : after(30m0s)"; !strings.Contains(got, want) {
+		t.Errorf("PlantUML script = %v, want contains %q", got, want)
+	}
+}
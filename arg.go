@@ -0,0 +1,40 @@
+package gofsm
+
+import "context"
+
+/**
+事件参数，通过 TriggerWithArg 传给 ArgAction 和 ArgGuard，通常是描述参数的结构体，例如 assign 事件的用户 ID
+*/
+type EventArg interface{}
+
+/**
+可以获取事件参数的 Action，Trigger 等没有参数的触发方式传入 nil
+*/
+type ArgAction func(ctx context.Context, from State, event Event, arg EventArg, to []State) (State, error)
+
+/**
+可以获取事件参数的 Guard，Trigger 等没有参数的触发方式以及 AvailableEventsCtx 传入 nil
+*/
+type ArgGuard func(ctx context.Context, from State, event Event, arg EventArg) (bool, error)
+
+/**
+触发带参数的事件，arg 传给 ArgAction 和 ArgGuard，普通的 Action 和 Guard 获取不到参数
+*/
+func (sm *StateMachine) TriggerWithArg(ctx context.Context, from State, event Event, arg EventArg) (State, error) {
+	result, err := sm.triggerX(ctx, from, event, triggerOptions{arg: arg})
+	return result.State, err
+}
+
+func (transfer *Transition) guarded() bool {
+	return transfer.Guard != nil || transfer.ArgGuard != nil
+}
+
+/**
+执行转换的条件，ArgGuard 优先
+*/
+func (transfer *Transition) guard(ctx context.Context, from State, event Event, arg EventArg) (bool, error) {
+	if transfer.ArgGuard != nil {
+		return transfer.ArgGuard(ctx, from, event, arg)
+	}
+	return transfer.Guard(ctx, from, event)
+}
@@ -0,0 +1,63 @@
+package gofsm_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+type assignArg struct {
+	UserID string
+}
+
+func TestStateMachine_TriggerWithArg(t *testing.T) {
+	var assigned string
+	assign := func(ctx context.Context, from gofsm.State, event gofsm.Event, arg gofsm.EventArg, to []gofsm.State) (gofsm.State, error) {
+		assigned = arg.(assignArg).UserID
+		return to[0], nil
+	}
+	admin := func(ctx context.Context, from gofsm.State, event gofsm.Event, arg gofsm.EventArg) (bool, error) {
+		a, ok := arg.(assignArg)
+		return ok && a.UserID == "admin", nil
+	}
+	sm := gofsm.New("ticket").
+		States(gofsm.StatesDef{"open": "", "assigned": "", "escalated": ""}).
+		Events(gofsm.EventsDef{"assign": ""}).
+		Transitions(
+			gofsm.Transition{From: "open", Event: "assign", To: []gofsm.State{"escalated"}, ArgAction: assign, ArgGuard: admin, Priority: 1},
+			gofsm.Transition{From: "open", Event: "assign", To: []gofsm.State{"assigned"}, ArgAction: assign},
+		)
+
+	tests := []struct {
+		name string
+		arg  gofsm.EventArg
+		want gofsm.State
+	}{
+		{"Guard Passes", assignArg{UserID: "admin"}, "escalated"},
+		{"Guard Rejects", assignArg{UserID: "alice"}, "assigned"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assigned = ""
+			got, err := sm.TriggerWithArg(context.TODO(), "open", "assign", tt.arg)
+			if err != nil || got != tt.want || assigned != tt.arg.(assignArg).UserID {
+				t.Errorf("StateMachine.TriggerWithArg() = %v, %v, assigned %q, want %v", got, err, assigned, tt.want)
+			}
+		})
+	}
+
+	t.Run("Without Arg", func(t *testing.T) {
+		guarded := gofsm.New("").
+			States(gofsm.StatesDef{"open": "", "escalated": ""}).
+			Events(gofsm.EventsDef{"assign": ""}).
+			Transitions(gofsm.Transition{From: "open", Event: "assign", To: []gofsm.State{"escalated"}, ArgAction: assign, ArgGuard: admin})
+		if _, err := guarded.Trigger(context.TODO(), "open", "assign"); !errors.Is(err, gofsm.ErrGuardRejected) {
+			t.Errorf("StateMachine.Trigger() error = %v, want %v", err, gofsm.ErrGuardRejected)
+		}
+		if !guarded.Spec().Transitions[0].Guarded {
+			t.Errorf("Spec().Transitions[0].Guarded = false, want true")
+		}
+	})
+}
@@ -0,0 +1,47 @@
+package gofsm
+
+import "fmt"
+
+/**
+为已定义的转换设置 Action，from、event 上的所有转换（包括 After 定时转换）都使用 a，同时清除 ArgAction
+用于从 JSON、CSV 等加载拓扑后绑定行为，转换不存在时返回 ErrNoTransition
+*/
+func (sm *StateMachine) SetAction(from State, event Event, a Action) error {
+	return sm.bind(from, event, func(transfer *Transition) {
+		transfer.Action, transfer.ArgAction = a, nil
+	})
+}
+
+/**
+为已定义的转换设置 Guard，同时清除 ArgGuard，转换不存在时返回错误
+*/
+func (sm *StateMachine) SetGuard(from State, event Event, guard Guard) error {
+	return sm.bind(from, event, func(transfer *Transition) {
+		transfer.Guard, transfer.ArgGuard = guard, nil
+	})
+}
+
+/**
+为已定义的转换设置事件处理器，替换原来的 Processor、ProcessorV2 和 ProcessorV3，转换不存在时返回错误
+*/
+func (sm *StateMachine) SetProcessor(from State, event Event, processor EventProcessor) error {
+	return sm.bind(from, event, func(transfer *Transition) {
+		transfer.Processor, transfer.ProcessorV2, transfer.ProcessorV3 = processor, nil, nil
+	})
+}
+
+func (sm *StateMachine) bind(from State, event Event, set func(transfer *Transition)) error {
+	sm.mutable()
+	from, event = sm.normState(from), sm.normEvent(event)
+	transfers := sm.sg.transitions[from][event]
+	if t, ok := sm.sg.timeouts[from]; ok && t.transfer.Event == event {
+		transfers = append(transfers[:len(transfers):len(transfers)], t.transfer)
+	}
+	if len(transfers) == 0 {
+		return fmt.Errorf("%w [%v --%v--> ???]", ErrNoTransition, from, event)
+	}
+	for _, transfer := range transfers {
+		set(transfer)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package gofsm_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestStateMachine_SetAction(t *testing.T) {
+	sm, err := gofsm.LoadCSV(strings.NewReader("from,event,to\n[*],,new\nnew,pay,paid\nnew,pay,new\npaid,,[*]\n"))
+	if err != nil {
+		t.Fatalf("LoadCSV() error = %v", err)
+	}
+	sm.After("paid", time.Minute, "new", nil)
+
+	called := 0
+	action := func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (gofsm.State, error) {
+		called++
+		return "paid", nil
+	}
+	if err := sm.SetAction("new", "pay", action); err != nil {
+		t.Fatalf("SetAction() error = %v", err)
+	}
+	if err := sm.SetAction("paid", "after(1m0s)", action); err != nil {
+		t.Errorf("SetAction() After error = %v", err)
+	}
+	if err := sm.SetAction("paid", "pay", action); !errors.Is(err, gofsm.ErrNoTransition) {
+		t.Errorf("SetAction() missing error = %v, want %v", err, gofsm.ErrNoTransition)
+	}
+	if got, err := sm.Trigger(context.TODO(), "new", "pay"); err != nil || got != "paid" || called != 1 {
+		t.Errorf("Trigger() = %v, %v, called %v, want paid", got, err, called)
+	}
+}
+
+func TestStateMachine_SetGuard(t *testing.T) {
+	sm := newOrderMachine()
+	reject := func(ctx context.Context, from gofsm.State, event gofsm.Event) (bool, error) {
+		return false, nil
+	}
+	if err := sm.SetGuard("new", "pay", reject); err != nil {
+		t.Fatalf("SetGuard() error = %v", err)
+	}
+	if _, err := sm.Trigger(context.TODO(), "new", "pay"); !errors.Is(err, gofsm.ErrGuardRejected) {
+		t.Errorf("Trigger() error = %v, want %v", err, gofsm.ErrGuardRejected)
+	}
+	if err := sm.SetGuard("sent", "pay", reject); !errors.Is(err, gofsm.ErrNoTransition) {
+		t.Errorf("SetGuard() missing error = %v, want %v", err, gofsm.ErrNoTransition)
+	}
+}
+
+func TestStateMachine_SetProcessor(t *testing.T) {
+	sm := newOrderMachine()
+	processor := &enterProcessor{}
+	if err := sm.SetProcessor("new", "pay", processor); err != nil {
+		t.Fatalf("SetProcessor() error = %v", err)
+	}
+	if _, err := sm.Trigger(context.TODO(), "new", "pay"); err != nil {
+		t.Fatalf("Trigger() error = %v", err)
+	}
+	if len(processor.entered) != 1 || processor.entered[0] != "paid" {
+		t.Errorf("OnEnter = %v, want [paid]", processor.entered)
+	}
+	if err := sm.SetProcessor("new", "send", processor); !errors.Is(err, gofsm.ErrNoTransition) {
+		t.Errorf("SetProcessor() missing error = %v, want %v", err, gofsm.ErrNoTransition)
+	}
+}
@@ -0,0 +1,61 @@
+package gofsm
+
+import "strings"
+
+/**
+状态和事件名称不区分大小写：States、Events、Transitions 等保存小写的名称，Trigger 查找前把 from、event 转为小写
+返回的状态也是小写；代价是不能再定义只有大小写不同的状态或事件，重复时后定义的覆盖先定义的
+需要在 States、Events、Transitions 等之前设置
+*/
+func (sm *StateMachine) CaseInsensitive(caseInsensitive bool) *StateMachine {
+	sm.mutable()
+	sm.caseInsensitive = caseInsensitive
+	return sm
+}
+
+func (sm *StateMachine) normState(state State) State {
+	if !sm.caseInsensitive {
+		return state
+	}
+	return State(strings.ToLower(string(state)))
+}
+
+func (sm *StateMachine) normEvent(event Event) Event {
+	if !sm.caseInsensitive {
+		return event
+	}
+	return Event(strings.ToLower(string(event)))
+}
+
+func (sm *StateMachine) normStateList(states []State) []State {
+	if !sm.caseInsensitive || states == nil {
+		return states
+	}
+	normalized := make([]State, len(states))
+	for i, state := range states {
+		normalized[i] = sm.normState(state)
+	}
+	return normalized
+}
+
+func (sm *StateMachine) normStatesDef(states StatesDef) StatesDef {
+	if !sm.caseInsensitive || states == nil {
+		return states
+	}
+	normalized := StatesDef{}
+	for state, desc := range states {
+		normalized[sm.normState(state)] = desc
+	}
+	return normalized
+}
+
+func (sm *StateMachine) normEventsDef(events EventsDef) EventsDef {
+	if !sm.caseInsensitive || events == nil {
+		return events
+	}
+	normalized := EventsDef{}
+	for event, desc := range events {
+		normalized[sm.normEvent(event)] = desc
+	}
+	return normalized
+}
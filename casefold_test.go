@@ -0,0 +1,59 @@
+package gofsm_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestStateMachine_CaseInsensitive(t *testing.T) {
+	newMachine := func(caseInsensitive bool) *gofsm.StateMachine {
+		return gofsm.New("order").
+			CaseInsensitive(caseInsensitive).
+			States(gofsm.StatesDef{"New": "", "Paid": ""}).
+			Events(gofsm.EventsDef{"Submit": ""}).
+			AliasEvent("Submit", "Pay").
+			Transitions(gofsm.Transition{From: "New", Event: "Submit", To: []gofsm.State{"Paid"}, Action: gofsm.NoopAction})
+	}
+
+	tests := []struct {
+		name            string
+		caseInsensitive bool
+		from            gofsm.State
+		event           gofsm.Event
+		want            gofsm.State
+		wantErr         bool
+	}{
+		{"Sensitive Exact", false, "New", "Submit", "Paid", false},
+		{"Sensitive Lower", false, "new", "submit", "", true},
+		{"Insensitive Exact", true, "New", "Submit", "paid", false},
+		{"Insensitive Mixed", true, "NEW", "sUbMiT", "paid", false},
+		{"Insensitive Alias", true, "new", "PAY", "paid", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newMachine(tt.caseInsensitive).Trigger(context.TODO(), tt.from, tt.event)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Trigger() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Trigger() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	sm := newMachine(true)
+	if got, want := sm.AvailableEvents("NEW"), []gofsm.Event{"submit"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("AvailableEvents() = %v, want %v", got, want)
+	}
+	i := sm.NewInstance("New")
+	if got, err := i.Fire(context.TODO(), "Submit"); err != nil || got != "paid" {
+		t.Errorf("Instance.Fire() = %v, %v, want paid", got, err)
+	}
+	m := sm.NewMultiInstance("New")
+	if got, err := m.Fire(context.TODO(), "Pay"); err != nil || !reflect.DeepEqual(got, []gofsm.State{"paid"}) {
+		t.Errorf("MultiInstance.Fire() = %v, %v, want [paid]", got, err)
+	}
+}
@@ -0,0 +1,111 @@
+package gofsm
+
+/**
+复制状态机，复制的状态机没有封存也没有实例，可以继续修改
+Action、Guard、Processor 等函数共享，状态图深度复制
+*/
+func (sm *StateMachine) Clone() *StateMachine {
+	return &StateMachine{
+		processor:       sm.processor,
+		logger:          sm.logger,
+		checkSinks:      sm.checkSinks,
+		checkReachable:  sm.checkReachable,
+		resolver:        sm.resolver,
+		nonePolicy:      sm.nonePolicy,
+		middlewares:     append([]Middleware(nil), sm.middlewares...),
+		freeze:          sm.freeze,
+		separate:        sm.separate,
+		recoverPanics:   sm.recoverPanics,
+		onUnknownState:  sm.onUnknownState,
+		onUnknownEvent:  sm.onUnknownEvent,
+		epsilon:         sm.epsilon,
+		beforeHooks:     append([]BeforeHook(nil), sm.beforeHooks...),
+		afterHooks:      append([]AfterHook(nil), sm.afterHooks...),
+		sortTargets:     sm.sortTargets,
+		caseInsensitive: sm.caseInsensitive,
+		fanOut:          sm.fanOut,
+		tracer:          sm.tracer,
+		permissive:      sm.permissive,
+		invariants:      copyInvariants(sm.invariants),
+		debugMode:       sm.debugMode,
+		sg:              sm.sg.clone(),
+	}
+}
+
+func (sg *stateGraph) clone() *stateGraph {
+	c := &stateGraph{
+		name:        sg.name,
+		start:       append([]State(nil), sg.start...),
+		end:         append([]State(nil), sg.end...),
+		transitions: map[State]map[Event][]*Transition{},
+		errorState:  sg.errorState,
+		deadState:   sg.deadState,
+		theme:       sg.theme,
+	}
+	if sg.states != nil {
+		c.states = StatesDef{}
+		for state, desc := range sg.states {
+			c.states[state] = desc
+		}
+	}
+	if sg.events != nil {
+		c.events = EventsDef{}
+		for event, desc := range sg.events {
+			c.events[event] = desc
+		}
+	}
+	for state, meta := range sg.meta {
+		if c.meta == nil {
+			c.meta = map[State]map[string]string{}
+		}
+		c.meta[state] = copyMeta(meta)
+	}
+	if sg.aliases != nil {
+		c.aliases = map[Event]Event{}
+		for alias, primary := range sg.aliases {
+			c.aliases[alias] = primary
+		}
+	}
+	for from, events := range sg.transitions {
+		c.transitions[from] = map[Event][]*Transition{}
+		for event, transfers := range events {
+			for _, transfer := range transfers {
+				copied := *transfer
+				copied.To = append([]State(nil), transfer.To...)
+				copied.Weights = append([]float64(nil), transfer.Weights...)
+				copied.Tags = append([]string(nil), transfer.Tags...)
+				c.transitions[from][event] = append(c.transitions[from][event], &copied)
+				if sg.pending[transfer] {
+					if c.pending == nil {
+						c.pending = map[*Transition]bool{}
+					}
+					c.pending[&copied] = true
+				}
+			}
+		}
+	}
+	sg.mu.RLock()
+	for key := range sg.disabled {
+		if c.disabled == nil {
+			c.disabled = map[transitionKey]bool{}
+		}
+		c.disabled[key] = true
+	}
+	sg.mu.RUnlock()
+	for state, t := range sg.timeouts {
+		if c.timeouts == nil {
+			c.timeouts = map[State]*timeout{}
+		}
+		transfer := *t.transfer
+		transfer.To = append([]State(nil), t.transfer.To...)
+		c.timeouts[state] = &timeout{after: t.after, transfer: &transfer}
+	}
+	for state, s := range sg.slas {
+		if c.slas == nil {
+			c.slas = map[State]*sla{}
+		}
+		copied := *s
+		c.slas[state] = &copied
+	}
+	return c
+}
@@ -0,0 +1,44 @@
+package gofsm_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestStateMachine_InUse(t *testing.T) {
+	sm := newOrderMachine()
+	sm.NewInstance("new")
+	defer func() {
+		if r := recover(); r == nil || !errors.Is(r.(error), gofsm.ErrInUse) {
+			t.Errorf("StateMachine.Transitions() after NewInstance panic = %v, want ErrInUse", r)
+		}
+	}()
+	sm.Transitions(gofsm.Transition{From: "sent", Event: "pay", To: []gofsm.State{"paid"}, Action: gofsm.NoopAction})
+}
+
+func TestStateMachine_Clone(t *testing.T) {
+	sm := newOrderMachine().Seal()
+	ins := sm.NewInstance("new")
+
+	clone := sm.Clone().
+		Transitions(gofsm.Transition{From: "sent", Event: "pay", To: []gofsm.State{"paid"}, Action: gofsm.NoopAction})
+	if clone.Sealed() {
+		t.Errorf("StateMachine.Clone().Sealed() = true, want false")
+	}
+	if got, err := clone.Trigger(context.TODO(), "sent", "pay"); err != nil || got != "paid" {
+		t.Errorf("clone.Trigger() = %v, %v, want paid", got, err)
+	}
+	if _, err := sm.Trigger(context.TODO(), "sent", "pay"); err == nil {
+		t.Errorf("StateMachine.Trigger() after clone modified, want error")
+	}
+	if got, err := ins.Fire(context.TODO(), "pay"); err != nil || got != "paid" {
+		t.Errorf("Instance.Fire() = %v, %v, want paid", got, err)
+	}
+	if !reflect.DeepEqual(newOrderMachine().Clone().Spec(), newOrderMachine().Spec()) {
+		t.Errorf("StateMachine.Clone().Spec() not equal to original")
+	}
+}
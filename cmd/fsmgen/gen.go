@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+/**
+状态机定义文件
+*/
+type spec struct {
+	Package string            `json:"package"`
+	States  map[string]string `json:"states"`
+	Events  map[string]string `json:"events"`
+}
+
+func parseSpec(data []byte) (*spec, error) {
+	s := &spec{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("解析定义文件失败: %v", err)
+	}
+	return s, nil
+}
+
+/**
+生成 go 源码
+*/
+func generate(s *spec) ([]byte, error) {
+	if s.Package == "" {
+		return nil, fmt.Errorf("没有指定 package 名称")
+	}
+	states, err := constNames("State", s.States)
+	if err != nil {
+		return nil, err
+	}
+	events, err := constNames("Event", s.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by fsmgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", s.Package)
+	fmt.Fprintf(&buf, "import \"github.com/threeq/gofsm\"\n\n")
+	writeConsts(&buf, "gofsm.State", states)
+	writeConsts(&buf, "gofsm.Event", events)
+	writeDef(&buf, "States", "gofsm.StatesDef", states, s.States)
+	writeDef(&buf, "Events", "gofsm.EventsDef", events, s.Events)
+
+	return format.Source(buf.Bytes())
+}
+
+/**
+生成常量名称，按原始名称排序，名称冲突时报错
+*/
+func constNames(prefix string, defs map[string]string) ([][2]string, error) {
+	var keys []string
+	for key := range defs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var names [][2]string
+	used := map[string]string{}
+	for _, key := range keys {
+		name := prefix + camelCase(key)
+		if name == prefix {
+			return nil, fmt.Errorf("无法为 %q 生成常量名称", key)
+		}
+		if other, ok := used[name]; ok {
+			return nil, fmt.Errorf("%q 和 %q 生成了相同的常量名称 %s", other, key, name)
+		}
+		used[name] = key
+		names = append(names, [2]string{name, key})
+	}
+	return names, nil
+}
+
+func writeConsts(buf *bytes.Buffer, typ string, names [][2]string) {
+	if len(names) == 0 {
+		return
+	}
+	buf.WriteString("const (\n")
+	for _, n := range names {
+		fmt.Fprintf(buf, "\t%s %s = %q\n", n[0], typ, n[1])
+	}
+	buf.WriteString(")\n\n")
+}
+
+func writeDef(buf *bytes.Buffer, varName, typ string, names [][2]string, defs map[string]string) {
+	fmt.Fprintf(buf, "var %s = %s{\n", varName, typ)
+	for _, n := range names {
+		fmt.Fprintf(buf, "\t%s: %q,\n", n[0], defs[n[1]])
+	}
+	buf.WriteString("}\n\n")
+}
+
+/**
+转换为驼峰形式：WAIT_PAY -> WaitPay, waitPay -> WaitPay
+*/
+func camelCase(s string) string {
+	parts := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, part := range parts {
+		if strings.ToUpper(part) == part {
+			part = strings.ToLower(part)
+		}
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_camelCase(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"NEW", "New"},
+		{"WAIT_PAY", "WaitPay"},
+		{"waitPay", "WaitPay"},
+		{"pay-failure", "PayFailure"},
+		{"v2", "V2"},
+		{"__", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := camelCase(tt.name); got != tt.want {
+				t.Errorf("camelCase() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_generate(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []string
+		wantErr bool
+	}{
+		{"Order",
+			`{"package":"order","states":{"NEW":"新建","WAIT_PAY":"待支付"},"events":{"pay":"支付"}}`,
+			[]string{
+				"package order",
+				`StateNew     gofsm.State = "NEW"`,
+				`StateWaitPay gofsm.State = "WAIT_PAY"`,
+				`EventPay gofsm.Event = "pay"`,
+				`StateNew:     "新建",`,
+				`EventPay: "支付",`,
+			}, false},
+		{"No Package", `{"states":{"NEW":""}}`, nil, true},
+		{"Name Conflict", `{"package":"p","states":{"WAIT_PAY":"","waitPay":""}}`, nil, true},
+		{"Invalid Name", `{"package":"p","events":{"--":""}}`, nil, true},
+		{"Invalid JSON", `{"package":`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := parseSpec([]byte(tt.spec))
+			var src []byte
+			if err == nil {
+				src, err = generate(s)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("generate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(src), want) {
+					t.Errorf("generate() = %s, want contains %s", src, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+/**
+fsmgen 根据状态机定义文件生成 State / Event 常量，避免裸字符串拼写错误
+
+	fsmgen -in order.json -out order_fsm.go -pkg order
+
+定义文件为 JSON 格式：
+
+	{
+		"package": "order",
+		"states": {"NEW": "新建", "PAID": "已支付"},
+		"events": {"pay": "支付"}
+	}
+
+也可以配合 go:generate 使用：
+
+	//go:generate fsmgen -in order.json -out order_fsm.go
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+func main() {
+	in := flag.String("in", "", "状态机定义文件 (JSON)")
+	out := flag.String("out", "", "生成的 go 文件，默认输出到标准输出")
+	pkg := flag.String("pkg", "", "生成文件的 package 名称，覆盖定义文件中的 package")
+	flag.Parse()
+
+	if *in == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(*in, *out, *pkg); err != nil {
+		fmt.Fprintln(os.Stderr, "fsmgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out, pkg string) error {
+	data, err := ioutil.ReadFile(in)
+	if err != nil {
+		return err
+	}
+	spec, err := parseSpec(data)
+	if err != nil {
+		return err
+	}
+	if pkg != "" {
+		spec.Package = pkg
+	}
+	src, err := generate(spec)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return ioutil.WriteFile(out, src, 0644)
+}
@@ -0,0 +1,101 @@
+package gofsm
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"qiniupkg.com/x/errors.v7"
+	"strings"
+)
+
+/**
+从 CSV 表格加载状态机，第一行为表头，包含 from、event、to、description 列（description 可选）
+	- 相同 from、event 的多行合并为 NFA 转换
+	- from 为 [*] 表示 to 是开始状态，to 为 [*] 表示 from 是结束状态；有 event 时是 From 为 Start、To 为 End 的转换
+	- description 作为事件的描述，同一事件使用第一个非空的描述
+加载后执行 Validate，所有转换使用 NoopAction
+*/
+func LoadCSV(r io.Reader) (*StateMachine, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("读取 CSV 表头失败: %v", err))
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"from", "event", "to"} {
+		if _, ok := columns[name]; !ok {
+			return nil, errors.New(fmt.Sprintf("CSV 表头缺少 %s 列", name))
+		}
+	}
+	reader.FieldsPerRecord = len(header)
+
+	states := StatesDef{}
+	events := EventsDef{}
+	var start, end []State
+	var transitions []Transition
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("读取 CSV 第 %d 行失败: %v", line, err))
+		}
+		column := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		from, event, to := State(column("from")), Event(column("event")), State(column("to"))
+		if from == None || to == None {
+			return nil, errors.New(fmt.Sprintf("CSV 第 %d 行缺少 from 或 to", line))
+		}
+
+		switch {
+		case from == pseudoState && to == pseudoState:
+			return nil, errors.New(fmt.Sprintf("CSV 第 %d 行 from 和 to 不能都是 %s", line, pseudoState))
+		case from == pseudoState && event == None:
+			states[to] = ""
+			start = append(start, to)
+			continue
+		case to == pseudoState && event == None:
+			states[from] = ""
+			end = append(end, from)
+			continue
+		case from == pseudoState:
+			from = Start
+			states[to] = ""
+		case to == pseudoState:
+			to = End
+			states[from] = ""
+		default:
+			states[from], states[to] = "", ""
+		}
+		if event != None && events[event] == "" {
+			events[event] = column("description")
+		}
+		transitions = append(transitions, Transition{From: from, Event: event, To: []State{to}, Action: NoopAction})
+	}
+
+	sm, err := define(func() *StateMachine {
+		return New("").
+			States(states).
+			Events(events).
+			Start(removeRepByMap(start)).
+			End(removeRepByMap(end)).
+			Transitions(transitions...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := sm.Validate(); err != nil {
+		return nil, err
+	}
+	return sm, nil
+}
@@ -0,0 +1,88 @@
+package gofsm_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestLoadCSV(t *testing.T) {
+	sm, err := gofsm.LoadCSV(strings.NewReader(`from,event,to,description
+[*],,new,
+new,pay,paid,支付
+new,pay,failed,
+new, cancel ,canceled,取消订单
+paid,cancel,canceled,
+paid,,[*],
+canceled,,[*],
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := sm.Spec()
+	if want := (gofsm.StatesDef{"new": "", "paid": "", "failed": "", "canceled": ""}); !reflect.DeepEqual(spec.States, want) {
+		t.Errorf("LoadCSV() states = %v, want %v", spec.States, want)
+	}
+	if want := (gofsm.EventsDef{"pay": "支付", "cancel": "取消订单"}); !reflect.DeepEqual(spec.Events, want) {
+		t.Errorf("LoadCSV() events = %v, want %v", spec.Events, want)
+	}
+	if !reflect.DeepEqual(spec.Start, []gofsm.State{"new"}) || !reflect.DeepEqual(spec.End, []gofsm.State{"paid", "canceled"}) {
+		t.Errorf("LoadCSV() start = %v, end = %v", spec.Start, spec.End)
+	}
+	want := []gofsm.SpecTransition{
+		{From: "new", Event: "cancel", To: []gofsm.State{"canceled"}},
+		{From: "new", Event: "pay", To: []gofsm.State{"paid", "failed"}},
+		{From: "paid", Event: "cancel", To: []gofsm.State{"canceled"}},
+	}
+	if !reflect.DeepEqual(spec.Transitions, want) {
+		t.Errorf("LoadCSV() transitions = %v, want %v", spec.Transitions, want)
+	}
+	if got, err := sm.Trigger(context.TODO(), "new", "pay"); err != nil || got != "paid" {
+		t.Errorf("StateMachine.Trigger() = %v, %v, want paid", got, err)
+	}
+}
+
+func TestLoadCSV_PseudoStateTransitions(t *testing.T) {
+	sm, err := gofsm.LoadCSV(strings.NewReader("from,event,to\n[*],,new\n[*],create,new\nnew,pay,paid\npaid,archive,[*]\npaid,,[*]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := gofsm.New("").
+		States(gofsm.StatesDef{"new": "", "paid": ""}).
+		Events(gofsm.EventsDef{"create": "", "pay": "", "archive": ""}).
+		Start([]gofsm.State{"new"}).
+		End([]gofsm.State{"paid"}).
+		Transitions(
+			gofsm.Transition{From: gofsm.Start, Event: "create", To: []gofsm.State{"new"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "new", Event: "pay", To: []gofsm.State{"paid"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "paid", Event: "archive", To: []gofsm.State{gofsm.End}, Action: gofsm.NoopAction},
+		)
+	if sm.Fingerprint() != want.Fingerprint() {
+		t.Errorf("LoadCSV().Spec() = %+v, want %+v", sm.Spec(), want.Spec())
+	}
+}
+
+func TestLoadCSV_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+	}{
+		{"Empty", ""},
+		{"Missing Column", "from,event\na,b\n"},
+		{"Missing To", "from,event,to\na,e,\n"},
+		{"Wrong Field Count", "from,event,to\na,e,b,c\n"},
+		{"Invalid Quote", "from,event,to\na,\"e,b\n"},
+		{"Start To End", "from,event,to\n[*],e,[*]\n"},
+		{"Reserved State", "from,event,to\na,e,[start]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := gofsm.LoadCSV(strings.NewReader(tt.csv)); err == nil {
+				t.Errorf("LoadCSV() want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package gofsm
+
+import "context"
+
+/**
+设置死状态，Trigger 没有定义 (from, event) 的转换（包括被 Disable 的转换）时进入该状态，不再返回 ErrNoTransition
+进入死状态时调用 OnEnter，不执行 Action、OnExit 和钩子；状态或事件不存在、Guard 不满足时仍然返回错误
+None 表示不设置
+*/
+func (sm *StateMachine) AutoDeadState(state State) *StateMachine {
+	sm.mutable()
+	sm.sg.deadState = sm.normState(state)
+	return sm
+}
+
+func (sm *StateMachine) enterDead(ctx context.Context, from State, event Event, opts triggerOptions) (State, error) {
+	dead := sm.sg.deadState
+	sm.debugf("enter dead state [%s] from [%s] on event [%s]: no transition", dead, from, event)
+	processor := sm.callProcessor(opts)
+	err := sm.safely(func() error {
+		_ = processor.OnEnter(ctx, from, event, dead)
+		return nil
+	})
+	return dead, err
+}
@@ -0,0 +1,65 @@
+package gofsm_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestStateMachine_AutoDeadState(t *testing.T) {
+	reject := func(ctx context.Context, from gofsm.State, event gofsm.Event) (bool, error) {
+		return false, nil
+	}
+	newMachine := func() *gofsm.StateMachine {
+		return newOrderMachine().
+			States(gofsm.StatesDef{"new": "", "paid": "", "sent": "", "imported": "", "dead": ""}).
+			Transitions(gofsm.Transition{From: "imported", Event: "pay", To: []gofsm.State{"paid"}, Action: gofsm.NoopAction, Guard: reject}).
+			AutoDeadState("dead")
+	}
+
+	tests := []struct {
+		name    string
+		from    gofsm.State
+		event   gofsm.Event
+		want    gofsm.State
+		entered []string
+		err     error
+	}{
+		{"Defined", "new", "pay", "paid", []string{"new-pay-paid"}, nil},
+		{"Undefined", "new", "send", "dead", []string{"new-send-dead"}, nil},
+		{"From Dead", "dead", "pay", "dead", []string{"dead-pay-dead"}, nil},
+		{"Guard Rejected", "imported", "pay", "", nil, gofsm.ErrGuardRejected},
+		{"Unknown Event", "new", "refund", "", nil, gofsm.ErrUnknownEvent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := &enterFromProcessor{}
+			got, err := newMachine().ProcessorV2(processor).Trigger(context.TODO(), tt.from, tt.event)
+			if !errors.Is(err, tt.err) || (tt.err == nil && got != tt.want) {
+				t.Errorf("StateMachine.Trigger() = %v, %v, want %v, %v", got, err, tt.want, tt.err)
+			}
+			if !reflect.DeepEqual(processor.entered, tt.entered) {
+				t.Errorf("OnEnter = %v, want %v", processor.entered, tt.entered)
+			}
+		})
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		sm := newMachine()
+		sm.Disable("new", "pay")
+		if got, err := sm.Trigger(context.TODO(), "new", "pay"); err != nil || got != "dead" {
+			t.Errorf("StateMachine.Trigger() = %v, %v, want dead", got, err)
+		}
+	})
+	t.Run("Validate", func(t *testing.T) {
+		if err := newMachine().Validate(); err != nil {
+			t.Errorf("StateMachine.Validate() error = %v", err)
+		}
+		if err := newOrderMachine().AutoDeadState("dead").Validate(); err == nil {
+			t.Errorf("StateMachine.Validate() want error for undefined dead state")
+		}
+	})
+}
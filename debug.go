@@ -0,0 +1,31 @@
+package gofsm
+
+import "fmt"
+
+/**
+调试模式，每次 Trigger 时检查状态机的一致性，发现问题时返回 ErrDebugCheck，用于开发和测试，生产环境不要开启
+	- 参与选择的目标状态数量不能超过 WarnFanOut 设置的阈值（WarnFanOut(1) 表示 DFA），在执行 Action 之前检查
+	- Action 返回的目标状态必须在 States 中定义（End 除外，StrictMembership(false) 时不检查），
+	  在 OnEnter 之前检查，与 Action 出错一样调用 OnActionFailure
+	- 通过 Logger 输出每次触发的候选状态、选中的状态、是否执行过 Guard 和结果
+关闭时没有额外开销
+*/
+func (sm *StateMachine) DebugMode(debug bool) *StateMachine {
+	sm.mutable()
+	sm.debugMode = debug
+	return sm
+}
+
+func (sm *StateMachine) debugCandidates(from State, event Event, candidates []State) error {
+	if n := len(removeRepByMap(candidates)); sm.fanOut > 0 && n > sm.fanOut {
+		return fmt.Errorf("%w [%v --%v--> %v]: 有 %d 个目标状态，超过 %d", ErrDebugCheck, from, event, candidates, n, sm.fanOut)
+	}
+	return nil
+}
+
+func (sm *StateMachine) debugTarget(from State, event Event, to State) error {
+	if sm.sg.contains(to) || sm.permissive {
+		return nil
+	}
+	return fmt.Errorf("%w [%v --%v--> %v]: 目标状态没有定义", ErrDebugCheck, from, event, to)
+}
@@ -0,0 +1,60 @@
+package gofsm_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestStateMachine_DebugMode(t *testing.T) {
+	lost := func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (gofsm.State, error) {
+		return "lost", nil
+	}
+	var executed bool
+	record := func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (gofsm.State, error) {
+		executed = true
+		return to[0], nil
+	}
+	newMachine := func() *gofsm.StateMachine {
+		return newOrderMachine().WarnFanOut(1).Transitions(
+			gofsm.Transition{From: "paid", Event: "pay", To: []gofsm.State{"new"}, Action: lost},
+			gofsm.Transition{From: "sent", Event: "send", To: []gofsm.State{"new", "paid"}, Action: record},
+		)
+	}
+
+	tests := []struct {
+		name     string
+		debug    bool
+		from     gofsm.State
+		event    gofsm.Event
+		want     gofsm.State
+		wantErr  bool
+		executed bool
+	}{
+		{"OK", true, "new", "pay", "paid", false, false},
+		{"Undeclared Target", true, "paid", "pay", "", true, false},
+		{"Undeclared Target Without Debug", false, "paid", "pay", "lost", false, false},
+		{"NFA In DFA", true, "sent", "send", "", true, false},
+		{"NFA In DFA Without Debug", false, "sent", "send", "new", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executed = false
+			logger := &recordLogger{}
+			got, err := newMachine().Logger(logger).DebugMode(tt.debug).Trigger(context.TODO(), tt.from, tt.event)
+			if tt.wantErr != errors.Is(err, gofsm.ErrDebugCheck) || (!tt.wantErr && (err != nil || got != tt.want)) {
+				t.Errorf("StateMachine.Trigger() = %v, %v, want %v, wantErr %v", got, err, tt.want, tt.wantErr)
+			}
+			if executed != tt.executed {
+				t.Errorf("Action executed = %v, want %v", executed, tt.executed)
+			}
+			logged := strings.Contains(strings.Join(logger.lines, "\n"), "candidates")
+			if logged != tt.debug {
+				t.Errorf("Logger lines = %v", logger.lines)
+			}
+		})
+	}
+}
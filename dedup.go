@@ -0,0 +1,74 @@
+package gofsm
+
+import (
+	"sort"
+	"strings"
+)
+
+/**
+重复的转换：与先匹配的转换 From、Event 相同且目标状态集合相同
+Action、Guard 等函数无法比较，不参与判断；返回重复转换的副本，不修改状态机
+*/
+func (sm *StateMachine) DuplicateTransitions() []Transition {
+	var duplicates []Transition
+	sm.sg.eachDuplicate(func(transfers []*Transition, i int) {
+		duplicates = append(duplicates, *transfers[i])
+	})
+	return duplicates
+}
+
+/**
+删除 DuplicateTransitions 中的重复转换，保留先匹配（优先级高）的一个，返回删除的数量
+*/
+func (sm *StateMachine) Dedup() int {
+	sm.mutable()
+	sg := sm.sg
+	removed := map[*Transition]bool{}
+	sg.eachDuplicate(func(transfers []*Transition, i int) {
+		removed[transfers[i]] = true
+	})
+	if len(removed) == 0 {
+		return 0
+	}
+	for _, events := range sg.transitions {
+		for event, transfers := range events {
+			kept := transfers[:0]
+			for _, transfer := range transfers {
+				if !removed[transfer] {
+					kept = append(kept, transfer)
+				}
+			}
+			events[event] = kept
+		}
+	}
+	return len(removed)
+}
+
+/**
+按 from、event 排序遍历重复的转换，transfers[i] 是重复的一个
+*/
+func (sg *stateGraph) eachDuplicate(fn func(transfers []*Transition, i int)) {
+	for _, from := range sg.sortedFroms() {
+		events := sg.transitions[from]
+		for _, event := range sortedTransitionEvents(events) {
+			transfers := events[event]
+			seen := map[string]bool{}
+			for i, transfer := range transfers {
+				key := targetsKey(transfer.To)
+				if seen[key] {
+					fn(transfers, i)
+				}
+				seen[key] = true
+			}
+		}
+	}
+}
+
+/**
+目标状态集合的键，与顺序无关
+*/
+func targetsKey(to []State) string {
+	names := stateStrings(to)
+	sort.Strings(names)
+	return strings.Join(names, "\x00")
+}
@@ -0,0 +1,54 @@
+package gofsm_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestStateMachine_Dedup(t *testing.T) {
+	newMachine := func() *gofsm.StateMachine {
+		return gofsm.New("").
+			SeparateTransitions(true).
+			States(gofsm.StatesDef{"a": "", "b": "", "c": ""}).
+			Events(gofsm.EventsDef{"e1": "", "e2": ""}).
+			Transitions(
+				gofsm.Transition{From: "a", Event: "e1", To: []gofsm.State{"b", "c"}, Action: gofsm.NoopAction, Priority: 1},
+				gofsm.Transition{From: "a", Event: "e1", To: []gofsm.State{"c", "b"}, Action: gofsm.NoopAction},
+				gofsm.Transition{From: "a", Event: "e1", To: []gofsm.State{"b"}, Action: gofsm.NoopAction},
+				gofsm.Transition{From: "b", Event: "e2", To: []gofsm.State{"c"}, Action: gofsm.NoopAction},
+				gofsm.Transition{From: "b", Event: "e2", To: []gofsm.State{"c"}, Action: gofsm.NoopAction},
+				gofsm.Transition{From: "b", Event: "e1", To: []gofsm.State{"c"}, Action: gofsm.NoopAction},
+			)
+	}
+
+	sm := newMachine()
+	var got []string
+	for _, transfer := range sm.DuplicateTransitions() {
+		got = append(got, transfer.String())
+	}
+	want := []string{
+		gofsm.Transition{From: "a", Event: "e1", To: []gofsm.State{"c", "b"}}.String(),
+		gofsm.Transition{From: "b", Event: "e2", To: []gofsm.State{"c"}}.String(),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("StateMachine.DuplicateTransitions() = %v, want %v", got, want)
+	}
+	if before := len(sm.Spec().Transitions); before != 6 {
+		t.Fatalf("len(Spec().Transitions) = %v, want 6", before)
+	}
+
+	if removed := sm.Dedup(); removed != 2 {
+		t.Errorf("StateMachine.Dedup() = %v, want 2", removed)
+	}
+	if after := len(sm.Spec().Transitions); after != 4 {
+		t.Errorf("len(Spec().Transitions) after Dedup = %v, want 4", after)
+	}
+	if got := sm.DuplicateTransitions(); len(got) != 0 {
+		t.Errorf("StateMachine.DuplicateTransitions() after Dedup = %v", got)
+	}
+	if removed := sm.Dedup(); removed != 0 {
+		t.Errorf("StateMachine.Dedup() again = %v, want 0", removed)
+	}
+}
@@ -0,0 +1,252 @@
+package gofsm
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+/**
+子集构造法把 NFA 转换为等价的 DFA，用于分析和显示
+	- DFA 的状态是原状态的集合，名称为排序后用 _ 连接的原状态名称，描述为原状态列表，
+	  不同集合的名称相同时（例如 {a_b} 和 {a, b}）panic，错误为 ErrSubsetName
+	- 开始状态是所有开始状态的 ε 闭包，每次转换的结果也取 ε 闭包，ε 转换本身不出现在 DFA 中
+	- 包含结束状态或者有转换到 End 的状态的集合是结束状态
+	- 相同状态相同事件的所有转换（包括有 Guard 和不同 Priority 的转换）的目标状态都视为可能的目标
+	- 转换的 Action 为 NoopAction，不保留 Guard、Processor 等
+没有开始状态时返回只包含事件的空状态机
+*/
+func (sm *StateMachine) ToDFA() *StateMachine {
+	sg := sm.sg
+	events := EventsDef{}
+	for event, desc := range sg.events {
+		events[event] = desc
+	}
+	dfa := New(sg.name).Events(events)
+
+	start := sg.closure(sg.roots())
+	if len(start) == 0 {
+		return dfa.States(StatesDef{})
+	}
+
+	eventNames := sortedEvents(sg.events)
+	names := subsetNames{}
+	states := StatesDef{}
+	var end []State
+	var transitions []Transition
+	queue := [][]State{start}
+	seen := map[State]bool{names.name(start): true}
+	for len(queue) > 0 {
+		set := queue[0]
+		queue = queue[1:]
+		name := names.name(set)
+		states[name] = subsetDesc(set)
+		for _, state := range set {
+			if sg.accepting(state) {
+				end = append(end, name)
+				break
+			}
+		}
+		for _, event := range eventNames {
+			if event == None {
+				continue
+			}
+			next := sg.move(set, event)
+			if len(next) == 0 {
+				continue
+			}
+			nextName := names.name(next)
+			transitions = append(transitions, Transition{From: name, Event: event, To: []State{nextName}, Action: NoopAction})
+			if !seen[nextName] {
+				seen[nextName] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return dfa.States(states).Start([]State{names.name(start)}).End(end).Transitions(transitions...)
+}
+
+/**
+结束状态或者有转换到 End 的状态
+*/
+func (sg *stateGraph) accepting(state State) bool {
+	if sg.isTerminal(state) {
+		return true
+	}
+	for _, transfers := range sg.transitions[state] {
+		for _, transfer := range transfers {
+			for _, to := range transfer.To {
+				if to == End {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+/**
+状态集合 set 在事件 event 下可以到达的所有状态，包含这些状态的 ε 闭包
+*/
+func (sg *stateGraph) move(set []State, event Event) []State {
+	var next []State
+	for _, state := range set {
+		for _, transfer := range sg.transitions[state][event] {
+			next = append(next, transfer.To...)
+		}
+	}
+	return sg.closure(next)
+}
+
+/**
+状态集合的 ε 闭包，去掉 None 和 End 后排序
+*/
+func (sg *stateGraph) closure(states []State) []State {
+	var closure []State
+	for _, state := range stateSet(states) {
+		closure = append(closure, sg.epsilonClose(state)...)
+	}
+	return stateSet(closure)
+}
+
+/**
+去掉重复、None 和 End 后排序
+*/
+func stateSet(states []State) []State {
+	var set []State
+	for _, state := range removeRepByMap(states) {
+		if state != None && state != End {
+			set = append(set, state)
+		}
+	}
+	sort.Slice(set, func(i, j int) bool { return set[i] < set[j] })
+	return set
+}
+
+func subsetName(set []State) State {
+	return State(strings.Join(stateStrings(set), "_"))
+}
+
+/**
+已经使用的集合名称，值为集合成员，用于发现不同集合名称相同的冲突
+*/
+type subsetNames map[State][]State
+
+func (names subsetNames) name(set []State) State {
+	name := subsetName(set)
+	if members, ok := names[name]; ok && !reflect.DeepEqual(members, set) {
+		panic(fmt.Errorf("%w: %s 和 %s 的名称都是 %s", ErrSubsetName, subsetDesc(members), subsetDesc(set), name))
+	}
+	names[name] = set
+	return name
+}
+
+func subsetDesc(set []State) string {
+	return "{" + strings.Join(stateStrings(set), ", ") + "}"
+}
+
+func stateStrings(states []State) []string {
+	names := make([]string, len(states))
+	for i, state := range states {
+		names[i] = string(state)
+	}
+	return names
+}
+
+func sortedEvents(events EventsDef) []Event {
+	var names []Event
+	for event := range events {
+		names = append(names, event)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+/**
+最小化 DFA：合并等价状态，按是否是结束状态划分后不断细分（Moore 算法）
+不是 DFA 或者有多个开始状态时先通过 ToDFA 转换，无法到达的状态被去掉，缺少的转换视为进入同一个死状态
+合并的状态名称为排序后用 _ 连接的 ToDFA 状态名称，描述为这些状态的列表，名称冲突时与 ToDFA 一样 panic
+*/
+func (sm *StateMachine) Minimize() *StateMachine {
+	dfa := sm.ToDFA()
+	sg := dfa.sg
+	if len(sg.start) == 0 {
+		return dfa
+	}
+
+	var states []State
+	for state := range sg.states {
+		states = append(states, state)
+	}
+	states = stateSet(states)
+	events := sortedEvents(sg.events)
+
+	block := map[State]int{}
+	for _, state := range states {
+		if sg.isTerminal(state) {
+			block[state] = 1
+		}
+	}
+	for count := 0; ; {
+		next := map[State]int{}
+		signatures := map[string]int{}
+		for _, state := range states {
+			signature := []int{block[state]}
+			for _, event := range events {
+				target := -1
+				if transfers := sg.transitions[state][event]; len(transfers) > 0 {
+					target = block[transfers[0].To[0]]
+				}
+				signature = append(signature, target)
+			}
+			key := fmt.Sprint(signature)
+			if _, ok := signatures[key]; !ok {
+				signatures[key] = len(signatures)
+			}
+			next[state] = signatures[key]
+		}
+		block = next
+		if len(signatures) == count {
+			break
+		}
+		count = len(signatures)
+	}
+
+	members := map[int][]State{}
+	for _, state := range states {
+		members[block[state]] = append(members[block[state]], state)
+	}
+	names := subsetNames{}
+	name := func(state State) State {
+		return names.name(members[block[state]])
+	}
+
+	minimized := StatesDef{}
+	for _, set := range members {
+		if len(set) == 1 {
+			minimized[names.name(set)] = sg.states[set[0]]
+		} else {
+			minimized[names.name(set)] = subsetDesc(set)
+		}
+	}
+	var end []State
+	var transitions []Transition
+	for _, set := range members {
+		if sg.isTerminal(set[0]) {
+			end = append(end, names.name(set))
+		}
+		for _, event := range events {
+			if transfers := sg.transitions[set[0]][event]; len(transfers) > 0 {
+				transitions = append(transitions, Transition{From: names.name(set), Event: event, To: []State{name(transfers[0].To[0])}, Action: NoopAction})
+			}
+		}
+	}
+	end = stateSet(end)
+	return New(sg.name).
+		States(minimized).
+		Events(sg.events).
+		Start([]State{name(sg.start[0])}).
+		End(end).
+		Transitions(transitions...)
+}
@@ -0,0 +1,200 @@
+package gofsm_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+/**
+以 01 结尾的 0/1 串
+*/
+func newEndsWith01NFA() *gofsm.StateMachine {
+	return gofsm.New("ends-with-01").
+		States(gofsm.StatesDef{"q0": "", "q1": "", "q2": ""}).
+		Events(gofsm.EventsDef{"0": "", "1": ""}).
+		Start([]gofsm.State{"q0"}).
+		End([]gofsm.State{"q2"}).
+		Transitions(
+			gofsm.Transition{From: "q0", Event: "0", To: []gofsm.State{"q0", "q1"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "q0", Event: "1", To: []gofsm.State{"q0"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "q1", Event: "1", To: []gofsm.State{"q2"}, Action: gofsm.NoopAction},
+		)
+}
+
+func TestStateMachine_ToDFA(t *testing.T) {
+	dfa := newEndsWith01NFA().ToDFA()
+	if !dfa.IsDeterministic() {
+		t.Fatalf("StateMachine.ToDFA().IsDeterministic() = false")
+	}
+
+	spec := dfa.Spec()
+	wantStates := gofsm.StatesDef{"q0": "{q0}", "q0_q1": "{q0, q1}", "q0_q2": "{q0, q2}"}
+	if !reflect.DeepEqual(spec.States, wantStates) {
+		t.Errorf("States = %v, want %v", spec.States, wantStates)
+	}
+	if !reflect.DeepEqual(spec.Start, []gofsm.State{"q0"}) || !reflect.DeepEqual(spec.End, []gofsm.State{"q0_q2"}) {
+		t.Errorf("Start = %v, End = %v", spec.Start, spec.End)
+	}
+	var edges []string
+	dfa.EachTransition(func(from gofsm.State, event gofsm.Event, to gofsm.State) bool {
+		edges = append(edges, string(from)+" "+string(event)+" "+string(to))
+		return true
+	})
+	wantEdges := []string{
+		"q0 0 q0_q1", "q0 1 q0",
+		"q0_q1 0 q0_q1", "q0_q1 1 q0_q2",
+		"q0_q2 0 q0_q1", "q0_q2 1 q0",
+	}
+	if !reflect.DeepEqual(edges, wantEdges) {
+		t.Errorf("transitions = %v, want %v", edges, wantEdges)
+	}
+
+	tests := []struct {
+		input  string
+		accept bool
+	}{
+		{"01", true},
+		{"1101", true},
+		{"010", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			state := gofsm.State("q0")
+			for _, c := range tt.input {
+				var err error
+				if state, err = dfa.Trigger(context.TODO(), state, gofsm.Event(c)); err != nil {
+					t.Fatalf("StateMachine.Trigger() error = %v", err)
+				}
+			}
+			if got := state == "q0_q2"; got != tt.accept {
+				t.Errorf("accept %q = %v, want %v", tt.input, got, tt.accept)
+			}
+		})
+	}
+}
+
+func TestStateMachine_ToDFA_Epsilon(t *testing.T) {
+	sm := gofsm.New("epsilon").
+		States(gofsm.StatesDef{"a": "", "b": "", "c": "", "d": ""}).
+		Events(gofsm.EventsDef{"x": "", "y": ""}).
+		Start([]gofsm.State{"a"}).
+		End([]gofsm.State{"d"}).
+		Transitions(
+			gofsm.Transition{From: "a", Event: gofsm.None, To: []gofsm.State{"b"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "b", Event: "x", To: []gofsm.State{"c"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "c", Event: gofsm.None, To: []gofsm.State{"d"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "d", Event: "y", To: []gofsm.State{"b"}, Action: gofsm.NoopAction},
+		)
+
+	dfa := sm.ToDFA()
+	spec := dfa.Spec()
+	wantStates := gofsm.StatesDef{"a_b": "{a, b}", "b": "{b}", "c_d": "{c, d}"}
+	if !reflect.DeepEqual(spec.States, wantStates) {
+		t.Errorf("States = %v, want %v", spec.States, wantStates)
+	}
+	if !reflect.DeepEqual(spec.Start, []gofsm.State{"a_b"}) || !reflect.DeepEqual(spec.End, []gofsm.State{"c_d"}) {
+		t.Errorf("Start = %v, End = %v", spec.Start, spec.End)
+	}
+	var edges []string
+	dfa.EachTransition(func(from gofsm.State, event gofsm.Event, to gofsm.State) bool {
+		edges = append(edges, string(from)+" "+string(event)+" "+string(to))
+		return true
+	})
+	wantEdges := []string{"a_b x c_d", "b x c_d", "c_d y b"}
+	if !reflect.DeepEqual(edges, wantEdges) {
+		t.Errorf("transitions = %v, want %v", edges, wantEdges)
+	}
+	if got := sm.Minimize().Spec().States; len(got) != 2 {
+		t.Errorf("StateMachine.Minimize() states = %v, want 2 states", got)
+	}
+}
+
+func TestStateMachine_ToDFA_EndTransition(t *testing.T) {
+	dfa := gofsm.New("").
+		States(gofsm.StatesDef{"a": "", "b": ""}).
+		Events(gofsm.EventsDef{"x": "", "done": ""}).
+		Start([]gofsm.State{"a"}).
+		Transitions(
+			gofsm.Transition{From: "a", Event: "x", To: []gofsm.State{"b"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "b", Event: "done", To: []gofsm.State{gofsm.End}, Action: gofsm.NoopAction},
+		).ToDFA()
+	if end := dfa.Spec().End; !reflect.DeepEqual(end, []gofsm.State{"b"}) {
+		t.Errorf("StateMachine.ToDFA() End = %v, want [b]", end)
+	}
+}
+
+func TestStateMachine_ToDFA_NameCollision(t *testing.T) {
+	sm := gofsm.New("").
+		States(gofsm.StatesDef{"a": "", "b": "", "a_b": ""}).
+		Events(gofsm.EventsDef{"x": "", "y": ""}).
+		Start([]gofsm.State{"a"}).
+		Transitions(
+			gofsm.Transition{From: "a", Event: "x", To: []gofsm.State{"a", "b"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "a", Event: "y", To: []gofsm.State{"a_b"}, Action: gofsm.NoopAction},
+		)
+	defer func() {
+		if r := recover(); r == nil || !errors.Is(r.(error), gofsm.ErrSubsetName) {
+			t.Errorf("recover() = %v, want %v", r, gofsm.ErrSubsetName)
+		}
+	}()
+	sm.ToDFA()
+}
+
+func TestStateMachine_ToDFA_NoStart(t *testing.T) {
+	if got := newEndsWith01NFA().Start(nil).ToDFA().Spec().States; len(got) != 0 {
+		t.Errorf("StateMachine.ToDFA() states = %v, want empty", got)
+	}
+}
+
+func TestStateMachine_Minimize(t *testing.T) {
+	sm := gofsm.New("min").
+		States(gofsm.StatesDef{"q0": "开始", "q1": "", "q2": "", "q3": "接受", "q4": "无法到达"}).
+		Events(gofsm.EventsDef{"a": "", "b": ""}).
+		Start([]gofsm.State{"q0"}).
+		End([]gofsm.State{"q3"}).
+		Transitions(
+			gofsm.Transition{From: "q0", Event: "a", To: []gofsm.State{"q1"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "q0", Event: "b", To: []gofsm.State{"q2"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "q1", Event: "a", To: []gofsm.State{"q3"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "q1", Event: "b", To: []gofsm.State{"q3"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "q2", Event: "a", To: []gofsm.State{"q3"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "q2", Event: "b", To: []gofsm.State{"q3"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "q3", Event: "a", To: []gofsm.State{"q3"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "q4", Event: "a", To: []gofsm.State{"q3"}, Action: gofsm.NoopAction},
+		)
+
+	tests := []struct {
+		name       string
+		sm         *gofsm.StateMachine
+		wantStates gofsm.StatesDef
+		wantEdges  []string
+	}{
+		{"Merge Equivalent", sm,
+			gofsm.StatesDef{"q0": "{q0}", "q1_q2": "{q1, q2}", "q3": "{q3}"},
+			[]string{"q0 a q1_q2", "q0 b q1_q2", "q1_q2 a q3", "q1_q2 b q3", "q3 a q3"}},
+		{"NFA", newEndsWith01NFA(),
+			gofsm.StatesDef{"q0": "{q0}", "q0_q1": "{q0, q1}", "q0_q2": "{q0, q2}"},
+			[]string{"q0 0 q0_q1", "q0 1 q0", "q0_q1 0 q0_q1", "q0_q1 1 q0_q2", "q0_q2 0 q0_q1", "q0_q2 1 q0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.sm.Minimize()
+			if states := got.Spec().States; !reflect.DeepEqual(states, tt.wantStates) {
+				t.Errorf("States = %v, want %v", states, tt.wantStates)
+			}
+			var edges []string
+			got.EachTransition(func(from gofsm.State, event gofsm.Event, to gofsm.State) bool {
+				edges = append(edges, string(from)+" "+string(event)+" "+string(to))
+				return true
+			})
+			if !reflect.DeepEqual(edges, tt.wantEdges) {
+				t.Errorf("transitions = %v, want %v", edges, tt.wantEdges)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package gofsm
+
+/**
+PlantUML 脚本和编码结果的缓存，gen 在失效时递增，避免并发生成的旧结果覆盖失效后的状态
+*/
+type diagramCache struct {
+	gen     uint64
+	valid   bool
+	script  string
+	encoded string
+}
+
+/**
+渲染后的 PlantUML 脚本和在线服务使用的编码，结果缓存到状态机修改（包括 Disable、Enable）为止
+封存后只有 Disable、Enable 会使缓存失效
+不使用 Fingerprint 作为缓存的 key：Fingerprint 不包含描述、主题、元数据和禁用的转换，这些都会影响图
+*/
+func (sg *stateGraph) cachedPlantUML() (string, string) {
+	sg.mu.RLock()
+	cache := sg.diagram
+	sg.mu.RUnlock()
+	if cache.valid {
+		return cache.script, cache.encoded
+	}
+
+	script := sg.plantUML()
+	encoded := encode(script)
+	sg.mu.Lock()
+	if sg.diagram.gen == cache.gen {
+		sg.diagram = diagramCache{gen: cache.gen, valid: true, script: script, encoded: encoded}
+	}
+	sg.mu.Unlock()
+	return script, encoded
+}
+
+/**
+修改状态机之前使缓存失效，构建方法不能与 Show 并发调用，没有缓存时不需要递增 gen
+*/
+func (sg *stateGraph) invalidateDiagram() {
+	sg.mu.Lock()
+	if sg.diagram.valid {
+		sg.diagram = diagramCache{gen: sg.diagram.gen + 1}
+	}
+	sg.mu.Unlock()
+}
@@ -0,0 +1,46 @@
+package gofsm
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_stateGraph_cachedPlantUML(t *testing.T) {
+	sm := New("order").
+		States(StatesDef{"new": "", "paid": ""}).
+		Events(EventsDef{"pay": ""}).
+		Transitions(Transition{From: "new", Event: "pay", To: []State{"paid"}, Action: NoopAction})
+
+	script, encoded := sm.sg.cachedPlantUML()
+	if script != sm.sg.plantUML() || encoded != encode(script) || !sm.sg.diagram.valid {
+		t.Fatalf("cachedPlantUML() = %q, %q, cache %+v", script, encoded, sm.sg.diagram)
+	}
+	sm.sg.diagram.script = "cached"
+	if got, _ := sm.sg.cachedPlantUML(); got != "cached" {
+		t.Errorf("cachedPlantUML() = %q, want cached result", got)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func()
+		want   string
+	}{
+		{"Builder", func() { sm.States(StatesDef{"new": "新建", "paid": ""}) }, "新建"},
+		{"Disable", func() { sm.Disable("new", "pay") }, "-[dashed]->"},
+		{"Enable", func() { sm.Enable("new", "pay") }, "-->"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mutate()
+			if got, _ := sm.sg.cachedPlantUML(); got == "cached" || !strings.Contains(got, tt.want) {
+				t.Errorf("cachedPlantUML() = %q, want %q", got, tt.want)
+			}
+			sm.sg.diagram.script = "cached"
+		})
+	}
+
+	sm.Seal()
+	if got, _ := sm.sg.cachedPlantUML(); got != "cached" {
+		t.Errorf("sealed cachedPlantUML() = %q, want cached result", got)
+	}
+}
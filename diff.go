@@ -0,0 +1,150 @@
+package gofsm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/**
+状态转换边，NFA 的多个目标状态展开为多条边
+*/
+type Edge struct {
+	From  State `json:"from"`
+	Event Event `json:"event"`
+	To    State `json:"to"`
+}
+
+func (e Edge) String() string {
+	return fmt.Sprintf("%s --(%s)--> %s", e.From, e.Event, e.To)
+}
+
+/**
+两个状态机定义的差异，结果都已排序
+*/
+type FSMDiff struct {
+	AddedStates        []State `json:"addedStates,omitempty"`
+	RemovedStates      []State `json:"removedStates,omitempty"`
+	AddedEvents        []Event `json:"addedEvents,omitempty"`
+	RemovedEvents      []Event `json:"removedEvents,omitempty"`
+	AddedTransitions   []Edge  `json:"addedTransitions,omitempty"`
+	RemovedTransitions []Edge  `json:"removedTransitions,omitempty"`
+}
+
+/**
+比较两个状态机，返回从 a 到 b 增加和删除的状态、事件和转换边
+*/
+func Diff(a, b *StateMachine) *FSMDiff {
+	diff := &FSMDiff{}
+
+	for _, state := range diffKeys(stateKeys(a.sg.states), stateKeys(b.sg.states)) {
+		diff.AddedStates = append(diff.AddedStates, State(state))
+	}
+	for _, state := range diffKeys(stateKeys(b.sg.states), stateKeys(a.sg.states)) {
+		diff.RemovedStates = append(diff.RemovedStates, State(state))
+	}
+	for _, event := range diffKeys(eventKeys(a.sg.events), eventKeys(b.sg.events)) {
+		diff.AddedEvents = append(diff.AddedEvents, Event(event))
+	}
+	for _, event := range diffKeys(eventKeys(b.sg.events), eventKeys(a.sg.events)) {
+		diff.RemovedEvents = append(diff.RemovedEvents, Event(event))
+	}
+
+	edgesA, edgesB := edgeSet(a), edgeSet(b)
+	for edge := range edgesB {
+		if !edgesA[edge] {
+			diff.AddedTransitions = append(diff.AddedTransitions, edge)
+		}
+	}
+	for edge := range edgesA {
+		if !edgesB[edge] {
+			diff.RemovedTransitions = append(diff.RemovedTransitions, edge)
+		}
+	}
+	sortEdges(diff.AddedTransitions)
+	sortEdges(diff.RemovedTransitions)
+	return diff
+}
+
+/**
+没有任何差异
+*/
+func (d *FSMDiff) Empty() bool {
+	return len(d.AddedStates) == 0 && len(d.RemovedStates) == 0 &&
+		len(d.AddedEvents) == 0 && len(d.RemovedEvents) == 0 &&
+		len(d.AddedTransitions) == 0 && len(d.RemovedTransitions) == 0
+}
+
+/**
+差异的文本描述，每行一项，+ 表示增加，- 表示删除
+*/
+func (d *FSMDiff) String() string {
+	if d.Empty() {
+		return "no changes"
+	}
+	var lines []string
+	for _, state := range d.AddedStates {
+		lines = append(lines, "+ state "+string(state))
+	}
+	for _, state := range d.RemovedStates {
+		lines = append(lines, "- state "+string(state))
+	}
+	for _, event := range d.AddedEvents {
+		lines = append(lines, "+ event "+string(event))
+	}
+	for _, event := range d.RemovedEvents {
+		lines = append(lines, "- event "+string(event))
+	}
+	for _, edge := range d.AddedTransitions {
+		lines = append(lines, "+ "+edge.String())
+	}
+	for _, edge := range d.RemovedTransitions {
+		lines = append(lines, "- "+edge.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func stateKeys(states StatesDef) map[string]bool {
+	keys := map[string]bool{}
+	for state := range states {
+		keys[string(state)] = true
+	}
+	return keys
+}
+
+func eventKeys(events EventsDef) map[string]bool {
+	keys := map[string]bool{}
+	for event := range events {
+		keys[string(event)] = true
+	}
+	return keys
+}
+
+/**
+在 b 中但不在 a 中的 key，排序后返回
+*/
+func diffKeys(a, b map[string]bool) []string {
+	var keys []string
+	for key := range b {
+		if !a[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func edgeSet(sm *StateMachine) map[Edge]bool {
+	edges := map[Edge]bool{}
+	sm.EachTransition(func(from State, event Event, to State) bool {
+		edges[Edge{From: from, Event: event, To: to}] = true
+		return true
+	})
+	return edges
+}
+
+func sortEdges(edges []Edge) {
+	sort.Slice(edges, func(i, j int) bool {
+		return edges[i].String() < edges[j].String()
+	})
+}
@@ -0,0 +1,61 @@
+package gofsm_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestDiff(t *testing.T) {
+	changed := func() *gofsm.StateMachine {
+		return gofsm.New("order").
+			States(gofsm.StatesDef{"new": "", "paid": "", "closed": ""}).
+			Events(gofsm.EventsDef{"pay": "", "close": ""}).
+			Transitions(
+				gofsm.Transition{From: "new", Event: "pay", To: []gofsm.State{"paid"}, Action: gofsm.NoopAction},
+				gofsm.Transition{From: "paid", Event: "close", To: []gofsm.State{"closed"}, Action: gofsm.NoopAction},
+			)
+	}
+
+	tests := []struct {
+		name string
+		a, b *gofsm.StateMachine
+		want *gofsm.FSMDiff
+	}{
+		{"Same", newOrderMachine(), newOrderMachine(), &gofsm.FSMDiff{}},
+		{"Changed", newOrderMachine(), changed(), &gofsm.FSMDiff{
+			AddedStates:        []gofsm.State{"closed"},
+			RemovedStates:      []gofsm.State{"imported", "sent"},
+			AddedEvents:        []gofsm.Event{"close"},
+			RemovedEvents:      []gofsm.Event{"send"},
+			AddedTransitions:   []gofsm.Edge{{From: "paid", Event: "close", To: "closed"}},
+			RemovedTransitions: []gofsm.Edge{{From: "paid", Event: "send", To: "sent"}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gofsm.Diff(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFSMDiff_String(t *testing.T) {
+	diff := gofsm.Diff(newOrderMachine(), newOrderMachine().
+		States(gofsm.StatesDef{"new": "", "paid": "", "sent": "", "imported": "", "done": ""}).
+		Transitions(gofsm.Transition{From: "sent", Event: "send", To: []gofsm.State{"done"}, Action: gofsm.NoopAction}))
+	if want := "+ state done\n+ sent --(send)--> done"; diff.String() != want {
+		t.Errorf("FSMDiff.String() = %q, want %q", diff.String(), want)
+	}
+	if got := gofsm.Diff(newOrderMachine(), newOrderMachine()).String(); got != "no changes" {
+		t.Errorf("FSMDiff.String() = %q, want no changes", got)
+	}
+
+	data, err := json.Marshal(diff)
+	if want := `{"addedStates":["done"],"addedTransitions":[{"from":"sent","event":"send","to":"done"}]}`; err != nil || string(data) != want {
+		t.Errorf("json.Marshal() = %s, %v, want %s", data, err, want)
+	}
+}
@@ -0,0 +1,59 @@
+package gofsm
+
+/**
+转换的键，用于记录禁用的转换和 Seal 时展开的转换索引
+*/
+type transitionKey struct {
+	from  State
+	event Event
+}
+
+/**
+运行时禁用 from 状态上 event 事件的所有转换，用于功能开关、维护等场景
+	- 禁用的转换在 Trigger 时视为不存在，返回 ErrNoTransition
+	- 不需要重新构建状态机，封存或者已创建实例的状态机也可以调用，并发安全
+	- 图中禁用的转换显示为虚线
+*/
+func (sm *StateMachine) Disable(from State, event Event) {
+	sg := sm.sg
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	if sg.disabled == nil {
+		sg.disabled = map[transitionKey]bool{}
+	}
+	sg.disabled[transitionKey{sm.normState(from), sm.normEvent(event)}] = true
+	sg.diagram = diagramCache{gen: sg.diagram.gen + 1}
+}
+
+/**
+重新启用被 Disable 禁用的转换
+*/
+func (sm *StateMachine) Enable(from State, event Event) {
+	sg := sm.sg
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	delete(sg.disabled, transitionKey{sm.normState(from), sm.normEvent(event)})
+	sg.diagram = diagramCache{gen: sg.diagram.gen + 1}
+}
+
+func (sg *stateGraph) isDisabled(from State, event Event) bool {
+	sg.mu.RLock()
+	defer sg.mu.RUnlock()
+	return sg.disabled[transitionKey{from, event}]
+}
+
+/**
+重命名状态或事件时修改禁用的转换
+*/
+func (sg *stateGraph) renameDisabled(rename func(key transitionKey) transitionKey) {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	if sg.disabled == nil {
+		return
+	}
+	disabled := map[transitionKey]bool{}
+	for key := range sg.disabled {
+		disabled[rename(key)] = true
+	}
+	sg.disabled = disabled
+}
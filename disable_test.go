@@ -0,0 +1,57 @@
+package gofsm_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestStateMachine_Disable(t *testing.T) {
+	sm := newOrderMachine().Seal()
+
+	sm.Disable("new", "pay")
+	if _, err := sm.Trigger(context.TODO(), "new", "pay"); !errors.Is(err, gofsm.ErrNoTransition) {
+		t.Errorf("Trigger() disabled error = %v, want %v", err, gofsm.ErrNoTransition)
+	}
+	if got := plantUMLScript(t, sm); !strings.Contains(got, "new -[dashed]-> paid") || !strings.Contains(got, "paid --> sent") {
+		t.Errorf("PlantUML script want dashed disabled edge, got\n%s", got)
+	}
+
+	sm.Enable("new", "pay")
+	if got, err := sm.Trigger(context.TODO(), "new", "pay"); err != nil || got != "paid" {
+		t.Errorf("Trigger() enabled = %v, %v, want paid", got, err)
+	}
+	if got := plantUMLScript(t, sm); strings.Contains(got, "dashed") {
+		t.Errorf("PlantUML script want no dashed edge, got\n%s", got)
+	}
+}
+
+func TestStateMachine_Disable_Concurrent(t *testing.T) {
+	sm := newOrderMachine()
+	i := sm.NewInstance("new")
+	var wg sync.WaitGroup
+	for n := 0; n < 50; n++ {
+		wg.Add(2)
+		go func(n int) {
+			defer wg.Done()
+			if n%2 == 0 {
+				sm.Disable("new", "pay")
+			} else {
+				sm.Enable("new", "pay")
+			}
+		}(n)
+		go func() {
+			defer wg.Done()
+			_, _ = sm.Trigger(context.TODO(), "new", "pay")
+		}()
+	}
+	wg.Wait()
+	sm.Enable("new", "pay")
+	if got, err := i.Fire(context.TODO(), "pay"); err != nil || got != "paid" {
+		t.Errorf("Instance.Fire() = %v, %v, want paid", got, err)
+	}
+}
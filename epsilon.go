@@ -0,0 +1,95 @@
+package gofsm
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+/**
+自动执行 ε 转换（Event 为 None 的转换）
+	- 触发事件时，如果当前状态没有该事件的转换，先沿 ε 转换前进到有该事件转换的状态
+	- 转换完成后继续沿 ε 转换前进，直到没有 ε 转换、Guard 不满足或者回到已经经过的状态
+ε 转换和普通转换一样执行 Guard、Action 和事件处理器，TriggerResult.Transition 为事件对应的转换
+*/
+func (sm *StateMachine) FollowEpsilon(follow bool) *StateMachine {
+	sm.mutable()
+	sm.epsilon = follow
+	return sm
+}
+
+/**
+ε 闭包：state 只通过 ε 转换可以到达的所有状态（包含 state 本身），按名称排序
+*/
+func (sm *StateMachine) EpsilonClose(state State) []State {
+	return sm.sg.epsilonClose(state)
+}
+
+func (sg *stateGraph) epsilonClose(state State) []State {
+	visited := map[State]bool{state: true}
+	closure := []State{state}
+	for i := 0; i < len(closure); i++ {
+		for _, transfer := range sg.transitions[closure[i]][None] {
+			for _, to := range transfer.To {
+				if to == None || to == End || visited[to] {
+					continue
+				}
+				visited[to] = true
+				closure = append(closure, to)
+			}
+		}
+	}
+	sort.Slice(closure, func(i, j int) bool { return closure[i] < closure[j] })
+	return closure
+}
+
+func (sm *StateMachine) triggerEpsilon(ctx context.Context, from State, event Event, opts triggerOptions) (TriggerResult, error) {
+	if !sm.epsilon || event == None || opts.transfer != nil {
+		return sm.trigger(ctx, from, event, opts)
+	}
+	primary := event
+	if alias, ok := sm.sg.aliases[event]; ok {
+		primary = alias
+	}
+	from, err := sm.followEpsilon(ctx, from, opts, func(state State) bool {
+		return len(sm.sg.transitions[state][primary]) > 0
+	})
+	if err != nil {
+		return TriggerResult{State: from}, err
+	}
+	result, err := sm.trigger(ctx, from, event, opts)
+	if err != nil || result.State == None {
+		return result, err
+	}
+	result.State, err = sm.followEpsilon(ctx, result.State, opts, nil)
+	return result, err
+}
+
+/**
+从 state 沿 ε 转换前进，stop 返回 true 时停止
+*/
+func (sm *StateMachine) followEpsilon(ctx context.Context, state State, opts triggerOptions, stop func(State) bool) (State, error) {
+	visited := map[State]bool{state: true}
+	for len(sm.sg.transitions[state][None]) > 0 && (stop == nil || !stop(state)) {
+		result, err := sm.trigger(ctx, state, None, opts)
+		if errors.Is(err, ErrGuardRejected) {
+			return state, nil
+		}
+		if err != nil {
+			if result.State != None {
+				return result.State, err
+			}
+			return state, err
+		}
+		if result.State == None || visited[result.State] {
+			if result.State != None {
+				state = result.State
+			}
+			return state, nil
+		}
+		sm.debugf("epsilon [%s] to [%s]", state, result.State)
+		visited[result.State] = true
+		state = result.State
+	}
+	return state, nil
+}
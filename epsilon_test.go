@@ -0,0 +1,80 @@
+package gofsm_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestStateMachine_FollowEpsilon(t *testing.T) {
+	reject := func(ctx context.Context, from gofsm.State, event gofsm.Event) (bool, error) {
+		return false, nil
+	}
+	newMachine := func(extra ...gofsm.Transition) *gofsm.StateMachine {
+		return gofsm.New("").
+			States(gofsm.StatesDef{"a": "", "b": "", "c": "", "d": "", "e": ""}).
+			Events(gofsm.EventsDef{"x": ""}).
+			Transitions(
+				gofsm.Transition{From: "a", Event: gofsm.None, To: []gofsm.State{"b"}, Action: gofsm.NoopAction},
+				gofsm.Transition{From: "b", Event: "x", To: []gofsm.State{"c"}, Action: gofsm.NoopAction},
+			).
+			Transitions(extra...)
+	}
+
+	tests := []struct {
+		name    string
+		sm      *gofsm.StateMachine
+		follow  bool
+		from    gofsm.State
+		event   gofsm.Event
+		want    gofsm.State
+		wantErr bool
+	}{
+		{"Disabled", newMachine(), false, "a", "x", "", true},
+		{"Explicit Epsilon", newMachine(), false, "a", gofsm.None, "b", false},
+		{"Before Event", newMachine(), true, "a", "x", "c", false},
+		{"After Event", newMachine(gofsm.Transition{From: "c", Event: gofsm.None, To: []gofsm.State{"d"}, Action: gofsm.NoopAction}), true, "a", "x", "d", false},
+		{"Guard Stops", newMachine(gofsm.Transition{From: "c", Event: gofsm.None, To: []gofsm.State{"d"}, Action: gofsm.NoopAction, Guard: reject}), true, "a", "x", "c", false},
+		{"Cycle Stops", newMachine(
+			gofsm.Transition{From: "c", Event: gofsm.None, To: []gofsm.State{"d"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "d", Event: gofsm.None, To: []gofsm.State{"c"}, Action: gofsm.NoopAction},
+		), true, "a", "x", "c", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.sm.FollowEpsilon(tt.follow).Trigger(context.TODO(), tt.from, tt.event)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("StateMachine.Trigger() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("StateMachine.Trigger() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStateMachine_EpsilonClose(t *testing.T) {
+	sm := gofsm.New("").
+		States(gofsm.StatesDef{"a": "", "b": "", "c": ""}).
+		Events(gofsm.EventsDef{"x": "", "y": ""}).
+		Transitions(
+			gofsm.Transition{From: "a", Event: gofsm.None, To: []gofsm.State{"b", gofsm.End}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "a", Event: "y", To: []gofsm.State{"c"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "b", Event: "x", To: []gofsm.State{"c"}, Action: gofsm.NoopAction},
+		)
+	if got := sm.EpsilonClose("a"); !reflect.DeepEqual(got, []gofsm.State{"a", "b"}) {
+		t.Errorf("StateMachine.EpsilonClose() = %v, want [a b]", got)
+	}
+	if got := sm.AvailableEvents("a"); !reflect.DeepEqual(got, []gofsm.Event{gofsm.None, "y"}) {
+		t.Errorf("StateMachine.AvailableEvents() = %v, want [ y]", got)
+	}
+	want := []gofsm.Event{"x", "y"}
+	if got := sm.FollowEpsilon(true).AvailableEvents("a"); !reflect.DeepEqual(got, want) {
+		t.Errorf("StateMachine.AvailableEvents() with epsilon = %v, want %v", got, want)
+	}
+	if got := sm.Seal().AvailableEvents("a"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Sealed StateMachine.AvailableEvents() with epsilon = %v, want %v", got, want)
+	}
+}
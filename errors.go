@@ -0,0 +1,23 @@
+package gofsm
+
+import "qiniupkg.com/x/errors.v7"
+
+/**
+预定义错误，可以通过 errors.Is 判断
+*/
+var (
+	ErrUnknownState    = errors.New("状态机不包含状态")
+	ErrUnknownEvent    = errors.New("状态机不包含事件")
+	ErrNoTransition    = errors.New("没有定义状态转换事件")
+	ErrGuardRejected   = errors.New("状态转换条件不满足")
+	ErrNoneTarget      = errors.New("Action 没有返回目标状态")
+	ErrTerminalState   = errors.New("结束状态不能再转换")
+	ErrSealed          = errors.New("状态机已封存，不能修改")
+	ErrPanic           = errors.New("执行发生 panic")
+	ErrInUse           = errors.New("状态机已创建实例，不能修改，请先 Clone")
+	ErrBudgetExhausted = errors.New("实例转换次数已用完")
+	ErrInvariant       = errors.New("状态不变式不满足")
+	ErrDebugCheck      = errors.New("调试检查失败")
+	ErrReservedState   = errors.New("状态名称是保留名称，开始和结束请使用 Start、End")
+	ErrSubsetName      = errors.New("DFA 状态名称冲突")
+)
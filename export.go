@@ -0,0 +1,216 @@
+package gofsm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/**
+导出格式
+*/
+type Format int
+
+const (
+	FormatPlantUML Format = iota // PlantUML 脚本，与 Show 中的脚本相同
+	FormatDOT                    // Graphviz DOT
+	FormatMermaid                // Mermaid stateDiagram-v2
+	FormatJSON                   // Spec 的 JSON
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatPlantUML:
+		return "plantuml"
+	case FormatDOT:
+		return "dot"
+	case FormatMermaid:
+		return "mermaid"
+	case FormatJSON:
+		return "json"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+/**
+按 format 把状态图写入 w，不会打开浏览器，返回写入的字节数
+输出是确定的：状态和转换按名称排序
+*/
+func (sm *StateMachine) WriteFormat(w io.Writer, format Format) (int64, error) {
+	var text string
+	switch format {
+	case FormatPlantUML:
+		text = sm.sg.plantUML()
+	case FormatDOT:
+		text = sm.sg.dot()
+	case FormatMermaid:
+		text = sm.sg.mermaid(nil)
+	case FormatJSON:
+		data, err := json.MarshalIndent(sm.Spec(), "", "  ")
+		if err != nil {
+			return 0, err
+		}
+		text = string(data) + "\n"
+	default:
+		return 0, fmt.Errorf("不支持的导出格式 %v", format)
+	}
+	n, err := io.WriteString(w, text)
+	return int64(n), err
+}
+
+/**
+实现 io.WriterTo，按 PlantUML 格式写入 w
+*/
+func (sm *StateMachine) WriteTo(w io.Writer) (int64, error) {
+	return sm.WriteFormat(w, FormatPlantUML)
+}
+
+/**
+Graphviz DOT 格式的状态图
+*/
+func (sm *StateMachine) ShowDOT() string {
+	return sm.sg.dot()
+}
+
+/**
+Mermaid 格式的状态图，可以直接粘贴到 Markdown 中
+*/
+func (sm *StateMachine) ShowMermaid() string {
+	return sm.sg.mermaid(nil)
+}
+
+/**
+Mermaid 格式的状态图，linkFor 返回非空地址的状态加上 click 链接，用于链接到状态的文档
+*/
+func (sm *StateMachine) ShowMermaidWithLinks(linkFor func(State) string) string {
+	return sm.sg.mermaid(linkFor)
+}
+
+/**
+导出用的边：开始、结束状态使用 Start、End
+*/
+type exportEdge struct {
+	from     State
+	to       State
+	label    string
+	disabled bool
+}
+
+/**
+排序后的状态和所有边：开始状态、转换、定时转换、结束状态
+*/
+func (sg *stateGraph) exportGraph() ([]State, []exportEdge) {
+	states := sortedStates(sg.states)
+	var edges []exportEdge
+	for _, state := range sg.start {
+		edges = append(edges, exportEdge{from: Start, to: state})
+	}
+	sg.each(func(transfer *Transition) bool {
+		disabled := sg.isDisabled(transfer.From, transfer.Event)
+		for _, to := range transfer.To {
+			edges = append(edges, exportEdge{from: transfer.From, to: to, label: sg.eventLabel(transfer.Event), disabled: disabled})
+		}
+		return true
+	})
+	for _, from := range sg.timeoutStates() {
+		transfer := sg.timeouts[from].transfer
+		edges = append(edges, exportEdge{from: from, to: transfer.To[0], label: string(transfer.Event)})
+	}
+	for _, state := range sg.end {
+		edges = append(edges, exportEdge{from: state, to: End})
+	}
+	return states, edges
+}
+
+func (sg *stateGraph) dot() string {
+	states, edges := sg.exportGraph()
+	id := func(state State) string {
+		switch state {
+		case Start:
+			return "__start"
+		case End:
+			return "__end"
+		}
+		return fmt.Sprintf("%q", string(state))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %q {\n", sg.name)
+	if strings.ToUpper(sg.plantUMLTheme().Direction) == "LR" {
+		b.WriteString("  rankdir=LR;\n")
+	}
+	b.WriteString("  __start [shape=point];\n")
+	b.WriteString("  __end [shape=doublecircle, label=\"\"];\n")
+	for _, state := range states {
+		label := string(state)
+		if desc := sg.states[state]; desc != "" {
+			label += "\n" + desc
+		}
+		attrs := fmt.Sprintf("label=%q", label)
+		if color := strings.TrimPrefix(sg.stateColor(state), "#"); color != "" {
+			attrs += fmt.Sprintf(", style=filled, fillcolor=%q", color)
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", id(state), attrs)
+	}
+	for _, edge := range edges {
+		var attrs []string
+		if edge.label != "" {
+			attrs = append(attrs, fmt.Sprintf("label=%q", edge.label))
+		}
+		if edge.disabled {
+			attrs = append(attrs, "style=dashed")
+		}
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, "  %s -> %s [%s];\n", id(edge.from), id(edge.to), strings.Join(attrs, ", "))
+		} else {
+			fmt.Fprintf(&b, "  %s -> %s;\n", id(edge.from), id(edge.to))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func (sg *stateGraph) mermaid(linkFor func(State) string) string {
+	states, edges := sg.exportGraph()
+	id := func(state State) string {
+		if state == Start || state == End {
+			return pseudoState
+		}
+		return string(state)
+	}
+
+	var b strings.Builder
+	b.WriteString("stateDiagram-v2\n")
+	if sg.name != "" {
+		fmt.Fprintf(&b, "  %%%% %s\n", sg.name)
+	}
+	switch strings.ToUpper(sg.plantUMLTheme().Direction) {
+	case "LR":
+		b.WriteString("  direction LR\n")
+	case "TB":
+		b.WriteString("  direction TB\n")
+	}
+	for _, state := range states {
+		if desc := sg.states[state]; desc != "" {
+			fmt.Fprintf(&b, "  %s : %s\n", state, desc)
+		} else {
+			fmt.Fprintf(&b, "  %s\n", state)
+		}
+	}
+	for _, edge := range edges {
+		if edge.label != "" {
+			fmt.Fprintf(&b, "  %s --> %s : %s\n", id(edge.from), id(edge.to), edge.label)
+		} else {
+			fmt.Fprintf(&b, "  %s --> %s\n", id(edge.from), id(edge.to))
+		}
+	}
+	if linkFor != nil {
+		for _, state := range states {
+			if url := linkFor(state); url != "" {
+				fmt.Fprintf(&b, "  click %s href \"%s\"\n", state, strings.ReplaceAll(url, `"`, "%22"))
+			}
+		}
+	}
+	return b.String()
+}
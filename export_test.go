@@ -0,0 +1,133 @@
+package gofsm_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func newExportMachine() *gofsm.StateMachine {
+	sm := gofsm.New("order").
+		States(gofsm.StatesDef{"new": "新建", "paid": ""}).
+		Events(gofsm.EventsDef{"pay": "", "cancel": ""}).
+		Start([]gofsm.State{"new"}).
+		End([]gofsm.State{"paid"}).
+		StateMeta("paid", map[string]string{gofsm.MetaColor: "LightBlue"}).
+		Transitions(
+			gofsm.Transition{From: "new", Event: "pay", To: []gofsm.State{"paid"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "new", Event: "cancel", To: []gofsm.State{gofsm.End}, Action: gofsm.NoopAction},
+		)
+	sm.Disable("new", "cancel")
+	return sm
+}
+
+func plantUMLScript(t *testing.T, sm *gofsm.StateMachine) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if _, err := sm.WriteFormat(&buf, gofsm.FormatPlantUML); err != nil {
+		t.Fatalf("StateMachine.WriteFormat() error = %v", err)
+	}
+	return buf.String()
+}
+
+func TestStateMachine_WriteFormat(t *testing.T) {
+	sm := newExportMachine()
+	tests := []struct {
+		name    string
+		format  gofsm.Format
+		want    string
+		wantErr bool
+	}{
+		{"DOT", gofsm.FormatDOT, "" +
+			"digraph \"order\" {\n" +
+			"  __start [shape=point];\n" +
+			"  __end [shape=doublecircle, label=\"\"];\n" +
+			"  \"new\" [label=\"new\\n新建\"];\n" +
+			"  \"paid\" [label=\"paid\", style=filled, fillcolor=\"LightBlue\"];\n" +
+			"  __start -> \"new\";\n" +
+			"  \"new\" -> __end [label=\"cancel\", style=dashed];\n" +
+			"  \"new\" -> \"paid\" [label=\"pay\"];\n" +
+			"  \"paid\" -> __end;\n" +
+			"}\n", false},
+		{"Mermaid", gofsm.FormatMermaid, "" +
+			"stateDiagram-v2\n" +
+			"  %% order\n" +
+			"  new : 新建\n" +
+			"  paid\n" +
+			"  [*] --> new\n" +
+			"  new --> [*] : cancel\n" +
+			"  new --> paid : pay\n" +
+			"  paid --> [*]\n", false},
+		{"Unknown", gofsm.Format(99), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			n, err := sm.WriteFormat(&b, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("StateMachine.WriteFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("StateMachine.WriteFormat() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if n != int64(b.Len()) {
+				t.Errorf("StateMachine.WriteFormat() n = %v, want %v", n, b.Len())
+			}
+		})
+	}
+}
+
+func TestStateMachine_WriteFormat_JSON(t *testing.T) {
+	sm := newExportMachine()
+	var b bytes.Buffer
+	if _, err := sm.WriteFormat(&b, gofsm.FormatJSON); err != nil {
+		t.Fatalf("StateMachine.WriteFormat() error = %v", err)
+	}
+	var spec gofsm.Spec
+	if err := json.Unmarshal(b.Bytes(), &spec); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if spec.Name != "order" || len(spec.Transitions) != 2 {
+		t.Errorf("StateMachine.WriteFormat() spec = %+v", spec)
+	}
+}
+
+func TestStateMachine_WriteTo(t *testing.T) {
+	sm := newExportMachine()
+	var _ io.WriterTo = sm
+	var b strings.Builder
+	if _, err := sm.WriteTo(&b); err != nil {
+		t.Fatalf("StateMachine.WriteTo() error = %v", err)
+	}
+	got := b.String()
+	if !strings.Contains(got, "@startuml") {
+		t.Errorf("StateMachine.WriteTo() = %v, want PlantUML script", got)
+	}
+	if !strings.Contains(sm.Show(), got) {
+		t.Errorf("StateMachine.Show() want contains WriteTo output")
+	}
+	if sm.ShowDOT() == "" || sm.ShowMermaid() == "" {
+		t.Errorf("ShowDOT() and ShowMermaid() want output")
+	}
+}
+
+func TestStateMachine_ShowMermaidWithLinks(t *testing.T) {
+	sm := newExportMachine()
+	got := sm.ShowMermaidWithLinks(func(state gofsm.State) string {
+		if state == "paid" {
+			return `https://wiki.example.com/order?state="paid"`
+		}
+		return ""
+	})
+	want := sm.ShowMermaid() + "  click paid href \"https://wiki.example.com/order?state=%22paid%22\"\n"
+	if got != want {
+		t.Errorf("StateMachine.ShowMermaidWithLinks() =\n%s\nwant\n%s", got, want)
+	}
+	if got := sm.ShowMermaidWithLinks(nil); got != sm.ShowMermaid() {
+		t.Errorf("StateMachine.ShowMermaidWithLinks(nil) = %s, want ShowMermaid()", got)
+	}
+}
@@ -0,0 +1,53 @@
+package gofsm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+)
+
+/**
+状态机拓扑的 SHA-256 指纹，用于比较不同服务中的状态机定义是否一致
+只包含状态、事件、开始状态、结束状态、错误状态和展开后的转换边，与名称、描述、Action、Guard 等无关
+*/
+func (sm *StateMachine) Fingerprint() string {
+	sg := sm.sg
+	h := sha256.New()
+	write := func(kind string, names []string) {
+		sort.Strings(names)
+		_, _ = fmt.Fprintf(h, "%s %d\n", kind, len(names))
+		for _, name := range names {
+			_, _ = fmt.Fprintf(h, "%q\n", name)
+		}
+	}
+
+	write("states", stateNames(sortedStates(sg.states)))
+	var events []string
+	for event := range sg.events {
+		events = append(events, string(event))
+	}
+	write("events", events)
+	write("start", stateNames(removeRepByMap(sg.start)))
+	write("end", stateNames(removeRepByMap(sg.end)))
+	write("error", []string{string(sg.errorState)})
+
+	var edges []Edge
+	for edge := range edgeSet(sm) {
+		edges = append(edges, edge)
+	}
+	sortEdges(edges)
+	_, _ = fmt.Fprintf(h, "transitions %d\n", len(edges))
+	for _, edge := range edges {
+		_, _ = fmt.Fprintf(h, "%q %q %q\n", edge.From, edge.Event, edge.To)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func stateNames(states []State) []string {
+	names := make([]string, 0, len(states))
+	for _, state := range states {
+		names = append(names, string(state))
+	}
+	return names
+}
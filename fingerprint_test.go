@@ -0,0 +1,47 @@
+package gofsm_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestStateMachine_Fingerprint(t *testing.T) {
+	base := newOrderMachine().Fingerprint()
+	if len(base) != 64 {
+		t.Fatalf("StateMachine.Fingerprint() = %q, want sha256 hex", base)
+	}
+
+	action := func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (gofsm.State, error) {
+		return to[0], nil
+	}
+	bound := newOrderMachine()
+	if err := bound.SetAction("new", "pay", action); err != nil {
+		t.Fatalf("StateMachine.SetAction() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		sm   *gofsm.StateMachine
+		same bool
+	}{
+		{"Same Topology", newOrderMachine(), true},
+		{"Different Action", bound, true},
+		{"Different Name And Desc", newOrderMachine().Name("other").
+			States(gofsm.StatesDef{"new": "", "paid": "", "sent": "", "imported": ""}), true},
+		{"Added State", newOrderMachine().
+			States(gofsm.StatesDef{"new": "", "paid": "", "sent": "", "imported": "", "closed": ""}), false},
+		{"Added Transition", newOrderMachine().Transitions(
+			gofsm.Transition{From: "new", Event: "pay", To: []gofsm.State{"imported"}, Action: gofsm.NoopAction},
+		), false},
+		{"Different End", newOrderMachine().End([]gofsm.State{"paid"}), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sm.Fingerprint(); (got == base) != tt.same {
+				t.Errorf("StateMachine.Fingerprint() = %v, base %v, want same %v", got, base, tt.same)
+			}
+		})
+	}
+}
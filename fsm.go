@@ -2,16 +2,19 @@ package gofsm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os/exec"
-	"qiniupkg.com/x/errors.v7"
 	"runtime"
+	"sort"
 	"strings"
+	"sync"
 )
 
 type State  string
 type Event  string
 type Action func(ctx context.Context, from State, event Event, to []State) (State, error)
+type Guard func(ctx context.Context, from State, event Event) (bool, error)
 type StatesDef map[State]string
 type EventsDef map[Event]string
 type EventProcessor interface {
@@ -19,12 +22,67 @@ type EventProcessor interface {
 	OnActionFailure(ctx context.Context, from State, event Event, to []State, err error) error
 	OnEnter(ctx context.Context, state State) error
 }
+
+/**
+事件处理器 v2，OnEnter 可以获取之前的状态和触发的事件
+EventProcessor 可以通过 AdaptProcessor 转换为 EventProcessorV2
+*/
+type EventProcessorV2 interface {
+	OnExit(ctx context.Context, state State, event Event) error
+	OnActionFailure(ctx context.Context, from State, event Event, to []State, err error) error
+	OnEnter(ctx context.Context, from State, event Event, to State) error
+}
+
+/**
+事件处理器 v3，OnActionFailure 可以返回恢复状态，返回 None 时与 v2 相同
+返回的状态不是 None 时进入该状态（调用 OnEnter），优先于错误状态，返回的 error 被忽略
+EventProcessorV2 可以通过 AdaptProcessorV2 转换为 EventProcessorV3
+*/
+type EventProcessorV3 interface {
+	OnExit(ctx context.Context, state State, event Event) error
+	OnActionFailure(ctx context.Context, from State, event Event, to []State, err error) (State, error)
+	OnEnter(ctx context.Context, from State, event Event, to State) error
+}
+
+/**
+日志输出接口
+*/
+type Logger interface {
+	Logf(format string, args ...interface{})
+}
+
+/**
+函数形式的 Logger，例如 LoggerFunc(log.Printf)
+*/
+type LoggerFunc func(format string, args ...interface{})
+
+func (f LoggerFunc) Logf(format string, args ...interface{}) {
+	f(format, args...)
+}
+
+/**
+状态转换定义
+	- Guard: 转换条件，nil 表示无条件
+	- Priority: 同一状态同一事件存在多个转换时，按 Priority 从大到小依次检查 Guard，使用第一个满足条件的转换
+*/
 type Transition struct {
 	From      State
 	Event     Event
 	To        []State
 	Action    Action
 	Processor EventProcessor
+	Guard     Guard
+	Priority  int
+
+	ProcessorV2 EventProcessorV2 // 优先于 Processor
+	ProcessorV3 EventProcessorV3 // 优先于 ProcessorV2
+	Weights     []float64        // 与 To 对应的权重，用于 WeightedResolver
+	Tags        []string         // 分类标签，用于 ShowFiltered，合并的转换合并标签
+	Progress    ProgressAction   // 优先于 Action，可以报告执行进度
+	Retry       Retry            // Action 失败重试配置
+	Desc        string           // 转换的描述，图中优先于事件的描述
+	ArgAction   ArgAction        // 优先于 Progress 和 Action，可以获取 TriggerWithArg 传入的参数
+	ArgGuard    ArgGuard         // 优先于 Guard，可以获取 TriggerWithArg 传入的参数
 }
 
 /**
@@ -39,15 +97,52 @@ type stateGraph struct {
 	end         []State
 	states      StatesDef
 	events      EventsDef
-	transitions map[State]map[Event]*Transition
+	transitions map[State]map[Event][]*Transition
+	aliases     map[Event]Event // 事件别名 -> 主事件
+	errorState  State           // Action 执行失败后进入的状态
+	deadState   State           // 没有定义转换时进入的状态
+	theme       PlantUMLTheme
+	eventIndex  map[State][]Event               // Seal 时建立的索引
+	compiled    map[transitionKey][]*Transition // Seal 时按 (from, event) 展开的转换
+	pending     map[*Transition]bool            // Transitions 合并后还没有去重的转换，Seal 时统一去重
+	timeouts    map[State]*timeout              // After 定义的定时转换
+	slas        map[State]*sla                  // StateSLA 定义的停留时间告警
+	meta        map[State]map[string]string     // StateMeta 设置的状态元数据
+
+	mu       sync.RWMutex           // 保护 disabled 和 diagram，运行时修改
+	disabled map[transitionKey]bool // Disable 禁用的转换
+	diagram  diagramCache           // Show 和 Handler 使用的 PlantUML 缓存
 }
 
 /**
 状态机
 */
 type StateMachine struct {
-	processor EventProcessor
-	sg        *stateGraph
+	processor       EventProcessorV3
+	logger          Logger
+	checkSinks      bool
+	checkReachable  bool
+	resolver        Resolver
+	nonePolicy      NonePolicy
+	middlewares     []Middleware
+	freeze          bool
+	sealed          bool
+	separate        bool
+	recoverPanics   bool
+	inUse           int32 // 已创建实例，原子操作
+	onUnknownState  func(State)
+	onUnknownEvent  func(Event)
+	epsilon         bool
+	beforeHooks     []BeforeHook
+	afterHooks      []AfterHook
+	sortTargets     bool
+	caseInsensitive bool
+	fanOut          int // WarnFanOut 设置的阈值
+	tracer          Tracer
+	permissive      bool // StrictMembership(false)
+	invariants      map[State][]func(ctx context.Context) error
+	debugMode       bool
+	sg              *stateGraph
 }
 
 /**
@@ -70,13 +165,31 @@ func (*DefaultProcessor) OnEnter(ctx context.Context, state State) error {
 	return nil
 }
 
+/**
+Action 返回 None 时的处理方式（只针对定义了目标状态的转换）
+	- NoneStay: 保持在原状态，不调用 OnEnter
+	- NoneError: 作为 Action 执行失败处理，返回 ErrNoneTarget
+*/
+type NonePolicy int
+
+const (
+	NoneStay NonePolicy = iota
+	NoneError
+)
+
 /**
 默认值定义
+Start、End 是不同的内部标记，图中都显示为 [*]；它们和 [*] 都是保留名称，不能作为状态名称
 */
-const Start = "[*]"
-const End = "[*]"
+const Start = "[start]"
+const End = "[end]"
 const None = ""
 
+/**
+PlantUML、CSV 中开始和结束状态的写法
+*/
+const pseudoState = "[*]"
+
 var NoopAction Action = func(ctx context.Context, from State, event Event, to []State) (State, error) {
 	if to == nil || len(to) == 0 {
 		return None, nil
@@ -85,20 +198,72 @@ var NoopAction Action = func(ctx context.Context, from State, event Event, to []
 }
 var NoopProcessor = &DefaultProcessor{}
 
+/**
+EventProcessor 转换为 EventProcessorV2，nil 转换后仍然是 nil
+*/
+func AdaptProcessor(processor EventProcessor) EventProcessorV2 {
+	if processor == nil {
+		return nil
+	}
+	return &processorAdapter{processor}
+}
+
+type processorAdapter struct {
+	processor EventProcessor
+}
+
+func (p *processorAdapter) OnExit(ctx context.Context, state State, event Event) error {
+	return p.processor.OnExit(ctx, state, event)
+}
+
+func (p *processorAdapter) OnActionFailure(ctx context.Context, from State, event Event, to []State, err error) error {
+	return p.processor.OnActionFailure(ctx, from, event, to, err)
+}
+
+func (p *processorAdapter) OnEnter(ctx context.Context, from State, event Event, to State) error {
+	return p.processor.OnEnter(ctx, to)
+}
+
+/**
+EventProcessorV2 转换为 EventProcessorV3，OnActionFailure 总是返回 None，nil 转换后仍然是 nil
+*/
+func AdaptProcessorV2(processor EventProcessorV2) EventProcessorV3 {
+	if processor == nil {
+		return nil
+	}
+	return &processorV2Adapter{processor}
+}
+
+type processorV2Adapter struct {
+	EventProcessorV2
+}
+
+func (p *processorV2Adapter) OnActionFailure(ctx context.Context, from State, event Event, to []State, err error) (State, error) {
+	return None, p.EventProcessorV2.OnActionFailure(ctx, from, event, to, err)
+}
+
 /**
 创建一个状态机执行器
 */
 func New(name string) *StateMachine {
 	return (&StateMachine{
 		sg: &stateGraph{
-			transitions: map[State]map[Event]*Transition{},
+			transitions: map[State]map[Event][]*Transition{},
 		}}).Name(name)
 }
 
 /**
 设置所有状态
+Start、End 和 [*] 是保留名称，定义为状态时 panic，错误为 ErrReservedState
 */
 func (sm *StateMachine) States(states StatesDef) *StateMachine {
+	sm.mutable()
+	states = sm.normStatesDef(states)
+	for state := range states {
+		if reservedState(state) {
+			panic(fmt.Errorf("%w: 状态 %s", ErrReservedState, state))
+		}
+	}
 	sm.sg.states = states
 	return sm
 }
@@ -107,60 +272,439 @@ func (sm *StateMachine) States(states StatesDef) *StateMachine {
 设置所有时间
 */
 func (sm *StateMachine) Events(events EventsDef) *StateMachine {
-	sm.sg.events = events
+	sm.mutable()
+	sm.sg.events = sm.normEventsDef(events)
+	return sm
+}
+
+/**
+设置事件别名，触发别名事件等同于触发主事件
+*/
+func (sm *StateMachine) AliasEvent(primary Event, aliases ...Event) *StateMachine {
+	sm.mutable()
+	if sm.sg.aliases == nil {
+		sm.sg.aliases = map[Event]Event{}
+	}
+	for _, alias := range aliases {
+		sm.sg.aliases[sm.normEvent(alias)] = sm.normEvent(primary)
+	}
 	return sm
 }
 
 func (sm *StateMachine) Name(s string) *StateMachine {
+	sm.mutable()
 	sm.sg.name = s
 	return sm
 }
 
 func (sm *StateMachine) Start(start []State) *StateMachine {
-	sm.sg.start = start
+	sm.mutable()
+	sm.sg.start = sm.normStateList(start)
 	return sm
 }
 
 func (sm *StateMachine) End(end []State) *StateMachine {
-	sm.sg.end = end
+	sm.mutable()
+	sm.sg.end = sm.normStateList(end)
+	return sm
+}
+
+/**
+设置错误状态，Action 执行失败后状态机进入该状态，EventProcessorV3 的 OnActionFailure 返回恢复状态时优先进入恢复状态
+*/
+func (sm *StateMachine) ErrorState(state State) *StateMachine {
+	sm.mutable()
+	sm.sg.errorState = sm.normState(state)
+	return sm
+}
+
+/**
+设置 Action 返回 None 时的处理方式，默认 NoneStay
+*/
+func (sm *StateMachine) OnNone(policy NonePolicy) *StateMachine {
+	sm.mutable()
+	sm.nonePolicy = policy
+	return sm
+}
+
+/**
+Trigger 时是否检查状态和事件在 States、Events 中定义，默认检查
+设置为 false 时只根据是否定义了转换判断，没有转换返回 ErrNoTransition，Validate 仍然检查定义
+*/
+func (sm *StateMachine) StrictMembership(strict bool) *StateMachine {
+	sm.mutable()
+	sm.permissive = !strict
+	return sm
+}
+
+/**
+冻结结束状态，设置后从 End 中的状态触发事件都返回 ErrTerminalState
+*/
+func (sm *StateMachine) FreezeTerminal(freeze bool) *StateMachine {
+	sm.mutable()
+	sm.freeze = freeze
 	return sm
 }
 
 func (sm *StateMachine) Processor(processor EventProcessor) *StateMachine {
+	sm.mutable()
+	sm.processor = AdaptProcessorV2(AdaptProcessor(processor))
+	return sm
+}
+
+func (sm *StateMachine) ProcessorV2(processor EventProcessorV2) *StateMachine {
+	sm.mutable()
+	sm.processor = AdaptProcessorV2(processor)
+	return sm
+}
+
+/**
+设置可以在 Action 失败后指定恢复状态的事件处理器
+*/
+func (sm *StateMachine) ProcessorV3(processor EventProcessorV3) *StateMachine {
+	sm.mutable()
 	sm.processor = processor
 	return sm
 }
 
+/**
+设置日志输出，nil 表示不输出日志
+*/
+func (sm *StateMachine) Logger(logger Logger) *StateMachine {
+	sm.mutable()
+	sm.logger = logger
+	return sm
+}
+
+/**
+状态机级别的事件处理器，没有设置时使用 NoopProcessor
+*/
+func (sm *StateMachine) machineProcessor() EventProcessorV3 {
+	if sm.processor == nil {
+		return AdaptProcessorV2(AdaptProcessor(NoopProcessor))
+	}
+	return sm.processor
+}
+
+/**
+转换使用的事件处理器：Transition.ProcessorV3 > Transition.ProcessorV2 > Transition.Processor > 状态机的处理器
+*/
+func (sm *StateMachine) transitionProcessor(transfer *Transition) EventProcessorV3 {
+	if transfer.ProcessorV3 != nil {
+		return transfer.ProcessorV3
+	}
+	if transfer.ProcessorV2 != nil {
+		return AdaptProcessorV2(transfer.ProcessorV2)
+	}
+	if transfer.Processor != nil {
+		return AdaptProcessorV2(AdaptProcessor(transfer.Processor))
+	}
+	return sm.machineProcessor()
+}
+
+func (sm *StateMachine) debugf(format string, args ...interface{}) {
+	if sm.logger == nil {
+		return
+	}
+	sm.logger.Logf("[DEBUG] gofsm(%s): "+format, append([]interface{}{sm.sg.name}, args...)...)
+}
+
+/**
+触发时 from 不是状态机中的状态时的回调，用于统计等，Trigger 仍然返回 ErrUnknownState
+*/
+func (sm *StateMachine) OnUnknownState(fn func(State)) *StateMachine {
+	sm.mutable()
+	sm.onUnknownState = fn
+	return sm
+}
+
+/**
+触发时事件不是状态机中的事件时的回调，用于统计等，Trigger 仍然返回 ErrUnknownEvent
+*/
+func (sm *StateMachine) OnUnknownEvent(fn func(Event)) *StateMachine {
+	sm.mutable()
+	sm.onUnknownEvent = fn
+	return sm
+}
+
+/**
+单独保存相同状态相同事件的转换，不合并目标状态
+触发时相同 Priority 的所有满足条件的转换的目标状态合并后交给 Resolver 选择（没有设置 Resolver 时选择第一个），
+然后执行被选中状态所属转换的 Action 和 Processor（包括 OnExit）
+需要在 Transitions 之前设置
+*/
+func (sm *StateMachine) SeparateTransitions(separate bool) *StateMachine {
+	sm.mutable()
+	sm.separate = separate
+	return sm
+}
+
 /**
 添加状态转换
+没有 Guard 且 Priority 相同的转换合并目标状态，其他转换按 Priority 从大到小排列
+目标状态去掉空状态 None，合并的目标状态只追加，Seal 时再去掉重复，设置 SortTargets 时按名称排序
+From 只能使用保留名称 Start，To 只能使用保留名称 End，否则 panic，错误为 ErrReservedState，不做任何修改
 TODO 不确定状态机，多个 Action 如何处理 ？？？
 */
 func (sm *StateMachine) Transitions(transitions ...Transition) *StateMachine {
+	sm.mutable()
+	for _, transfer := range transitions {
+		if from := sm.normState(transfer.From); reservedState(from) && from != Start {
+			panic(fmt.Errorf("%w: 转换 %s 的状态 %s", ErrReservedState, transfer, from))
+		}
+		for _, to := range sm.normStateList(transfer.To) {
+			if reservedState(to) && to != End {
+				panic(fmt.Errorf("%w: 转换 %s 的目标状态 %s", ErrReservedState, transfer, to))
+			}
+		}
+	}
+	// 合并的目标状态只追加，Seal 时统一去重，避免多次调用 Transitions 时每次都重新去重
 	for index := range transitions {
 		newTransfer := &transitions[index]
+		newTransfer.From, newTransfer.Event = sm.normState(newTransfer.From), sm.normEvent(newTransfer.Event)
+		newTransfer.To = sm.normStateList(newTransfer.To)
+		if newTransfer.Weights == nil {
+			newTransfer.To = withoutNone(newTransfer.To)
+		}
 		events, ok := sm.sg.transitions[newTransfer.From]
 		if !ok {
-			events = map[Event]*Transition{}
+			events = map[Event][]*Transition{}
 			sm.sg.transitions[newTransfer.From] = events
 		}
-		if transfer, ok := events[newTransfer.Event]; ok {
+		transfer := sm.mergeable(events[newTransfer.Event], newTransfer)
+		if transfer != nil && (transfer.Weights != nil || newTransfer.Weights != nil) {
+			transfer.To, transfer.Weights = mergeWeighted(transfer, newTransfer)
+			transfer.Tags = mergeTags(transfer.Tags, newTransfer.Tags)
+			transfer.Desc = mergeDesc(transfer.Desc, newTransfer.Desc)
+		} else if transfer != nil {
 			transfer.To = append(transfer.To, newTransfer.To...)
-			// 去掉重复
-			//sort.Strings(transfer.To)
-			transfer.To = removeRepByMap(transfer.To)
-			events[newTransfer.Event] = transfer
+			transfer.Tags = mergeTags(transfer.Tags, newTransfer.Tags)
+			transfer.Desc = mergeDesc(transfer.Desc, newTransfer.Desc)
 		} else {
-			events[newTransfer.Event] = newTransfer
+			if newTransfer.Weights != nil {
+				newTransfer.To, newTransfer.Weights = mergeWeighted(newTransfer, &Transition{})
+			}
+			events[newTransfer.Event] = insertByPriority(events[newTransfer.Event], newTransfer)
+			transfer = newTransfer
+		}
+		if sm.sg.pending == nil {
+			sm.sg.pending = map[*Transition]bool{}
 		}
+		sm.sg.pending[transfer] = true
 	}
 	return sm
 }
 
-//slice去重
+/**
+执行状态机定义，定义时使用保留名称的 panic 转换为错误返回，用于从文件加载状态机
+*/
+func define(fn func() *StateMachine) (sm *StateMachine, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok && errors.Is(e, ErrReservedState) {
+				sm, err = nil, e
+				return
+			}
+			panic(r)
+		}
+	}()
+	return fn(), nil
+}
+
+/**
+合并目标状态后按名称排序，默认保持添加顺序
+需要在 Transitions 之前设置
+*/
+func (sm *StateMachine) SortTargets(sortTargets bool) *StateMachine {
+	sm.mutable()
+	sm.sortTargets = sortTargets
+	return sm
+}
+
+/**
+目标状态按名称排序，权重跟随目标状态
+*/
+func (transfer *Transition) sortTargets() {
+	if transfer.Weights == nil {
+		sort.Slice(transfer.To, func(i, j int) bool { return transfer.To[i] < transfer.To[j] })
+		return
+	}
+	index := make([]int, len(transfer.To))
+	for i := range index {
+		index[i] = i
+	}
+	sort.SliceStable(index, func(i, j int) bool { return transfer.To[index[i]] < transfer.To[index[j]] })
+	to := make([]State, len(index))
+	weights := make([]float64, len(index))
+	for i, k := range index {
+		to[i], weights[i] = transfer.To[k], weightAt(transfer.Weights, k)
+	}
+	transfer.To, transfer.Weights = to, weights
+}
+
+/**
+查找可以合并的已有转换，SeparateTransitions 时不合并
+*/
+func (sm *StateMachine) mergeable(transfers []*Transition, newTransfer *Transition) *Transition {
+	if sm.separate || newTransfer.guarded() {
+		return nil
+	}
+	for _, transfer := range transfers {
+		if !transfer.guarded() && transfer.Priority == newTransfer.Priority {
+			return transfer
+		}
+	}
+	return nil
+}
+
+/**
+合并标签，去掉重复并保持顺序
+*/
+func mergeTags(tags, more []string) []string {
+	for _, tag := range more {
+		if !containsTag(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+/**
+合并转换的描述，保留先定义的描述
+*/
+func mergeDesc(desc, more string) string {
+	if desc != "" {
+		return desc
+	}
+	return more
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+按 Priority 从大到小插入，相同 Priority 保持添加顺序
+*/
+func insertByPriority(transfers []*Transition, newTransfer *Transition) []*Transition {
+	i := sort.Search(len(transfers), func(i int) bool {
+		return transfers[i].Priority < newTransfer.Priority
+	})
+	transfers = append(transfers, nil)
+	copy(transfers[i+1:], transfers[i:])
+	transfers[i] = newTransfer
+	return transfers
+}
+
+/**
+按优先级选择第一个满足 Guard 的转换，同时返回是否执行过 Guard
+SeparateTransitions 时返回与第一个满足条件的转换 Priority 相同的所有满足条件的转换
+*/
+func (sm *StateMachine) match(ctx context.Context, from State, event Event, arg EventArg) ([]*Transition, bool, error) {
+	transfers := sm.sg.lookup(from, event)
+	if len(transfers) == 0 || sm.sg.isDisabled(from, event) {
+		return nil, false, fmt.Errorf("%w [%v --%v--> ???]", ErrNoTransition, from, event)
+	}
+	guarded := false
+	var matched []*Transition
+	for _, transfer := range transfers {
+		if len(matched) > 0 && (!sm.separate || transfer.Priority != matched[0].Priority) {
+			break
+		}
+		pass := true
+		if transfer.guarded() {
+			guarded = true
+			err := sm.safely(func() (err error) {
+				pass, err = transfer.guard(ctx, from, event, arg)
+				return err
+			})
+			if err != nil {
+				return nil, guarded, err
+			}
+		}
+		if pass {
+			matched = append(matched, transfer)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, guarded, fmt.Errorf("%w [%v --%v--> ???]", ErrGuardRejected, from, event)
+	}
+	return matched, guarded, nil
+}
+
+/**
+选择目标状态，返回执行的转换、传给 Action 的目标状态和参与选择的所有目标状态
+	- 只有一个转换时，设置了 Resolver 且有多个目标状态才由 Resolver 选择
+	- 多个转换时合并所有目标状态由 Resolver 选择（没有设置时选择第一个），使用被选中状态所属的转换
+*/
+func (sm *StateMachine) resolve(from State, event Event, transfers []*Transition) (*Transition, []State, []State) {
+	if len(transfers) == 1 {
+		transfer := transfers[0]
+		if sm.resolver == nil || len(transfer.To) < 2 {
+			return transfer, transfer.To, transfer.To
+		}
+		chosen := sm.resolver(from, event, transfer.To, transfer.Weights)
+		sm.debugf("resolve %s --(%s)--> %v: %s", from, event, transfer.To, chosen)
+		return transfer, []State{chosen}, transfer.To
+	}
+
+	var to []State
+	var weights []float64
+	for _, transfer := range transfers {
+		for i, state := range transfer.To {
+			to = append(to, state)
+			weights = append(weights, weightAt(transfer.Weights, i))
+		}
+	}
+	var chosen State
+	if len(to) > 0 {
+		chosen = to[0]
+	}
+	if sm.resolver != nil {
+		chosen = sm.resolver(from, event, to, weights)
+	}
+	sm.debugf("resolve %s --(%s)--> %v: %s", from, event, to, chosen)
+	for _, transfer := range transfers {
+		for _, state := range transfer.To {
+			if state == chosen {
+				return transfer, []State{chosen}, to
+			}
+		}
+	}
+	return transfers[0], []State{chosen}, to
+}
+
+/**
+去掉空状态 None，没有空状态时返回原切片
+*/
+func withoutNone(states []State) []State {
+	for i, state := range states {
+		if state == None {
+			result := append([]State(nil), states[:i]...)
+			for _, state := range states[i+1:] {
+				if state != None {
+					result = append(result, state)
+				}
+			}
+			return result
+		}
+	}
+	return states
+}
+
+//slice去重，同时去掉空状态 None
 func removeRepByMap(slc []State) []State {
 	result := []State{}         //存放返回的不重复切片
 	tempMap := map[State]byte{} // 存放不重复主键
 	for _, e := range slc {
+		if e == None {
+			continue
+		}
 		l := len(tempMap)
 		tempMap[e] = 0 //当e存在于tempMap中时，再次添加是添加不进去的，，因为key不允许重复
 		//如果上一行添加成功，那么长度发生变化且此时元素一定不重复
@@ -170,63 +714,314 @@ func removeRepByMap(slc []State) []State {
 	}
 	return result
 }
-func removeDuplicatesAndEmpty(a []State) (ret []State) {
-	aLen := len(a)
-	for i := 0; i < aLen; i++ {
-		if (i > 0 && a[i-1] == a[i]) || len(a[i]) == 0 {
-			continue
-		}
-		ret = append(ret, a[i])
-	}
-	return
-}
 
 /**
 触发状态转换
 */
 func (sm *StateMachine) Trigger(ctx context.Context, from State, event Event) (State, error) {
-	if _, ok := sm.sg.states[from]; !ok {
-		return "", errors.New(fmt.Sprintf("状态机不包含状态%s", from))
+	result, err := sm.TriggerX(ctx, from, event)
+	return result.State, err
+}
+
+/**
+事件可以触发时触发状态转换，否则停留在 from，返回的 bool 表示是否执行了转换
+没有转换、Guard 不通过、结束状态被冻结时不返回错误；Guard、Action 出错等真正的失败才返回错误
+*/
+func (sm *StateMachine) TriggerOrStay(ctx context.Context, from State, event Event) (State, bool, error) {
+	state, err := sm.Trigger(ctx, from, event)
+	switch {
+	case err == nil:
+		return state, true, nil
+	case errors.Is(err, ErrNoTransition), errors.Is(err, ErrGuardRejected), errors.Is(err, ErrTerminalState):
+		return from, false, nil
+	}
+	return state, false, err
+}
+
+/**
+状态转换结果
+	- State: 转换后的状态
+	- Transition: 执行的状态转换（副本），没有匹配到转换时为零值
+	- GuardEvaluated: 匹配转换时是否执行过 Guard
+	- Recovered: Action 失败后进入了 OnActionFailure 返回的恢复状态或者错误状态
+	- Candidates: 选择目标状态时考虑的所有目标状态，NFA 转换有多个
+	- Chosen: Resolver 选择的或者唯一的目标状态；没有 Resolver 的 NFA 转换由 Action 选择，为 Action 成功后的状态，失败时为 None
+*/
+type TriggerResult struct {
+	State          State
+	Transition     Transition
+	GuardEvaluated bool
+	Recovered      bool
+	Candidates     []State
+	Chosen         State
+}
+
+/**
+触发状态转换，返回转换的详细信息
+*/
+func (sm *StateMachine) TriggerX(ctx context.Context, from State, event Event) (TriggerResult, error) {
+	return sm.triggerX(ctx, from, event, triggerOptions{})
+}
+
+/**
+触发状态转换，本次调用使用 p 作为事件处理器，优先于转换和状态机的处理器
+*/
+func (sm *StateMachine) TriggerWithProcessor(ctx context.Context, from State, event Event, p EventProcessor) (State, error) {
+	result, err := sm.triggerX(ctx, from, event, triggerOptions{processor: AdaptProcessorV2(AdaptProcessor(p))})
+	return result.State, err
+}
+
+/**
+单次触发的选项
+*/
+type triggerOptions struct {
+	processor EventProcessorV3
+	transfer  *Transition // 直接执行的转换，不按事件查找，用于定时转换
+	emit      func(progress interface{})
+	target    State // 与 transfer 一起使用，指定传给 Action 的目标状态
+	arg       EventArg
+}
+
+func (sm *StateMachine) triggerX(ctx context.Context, from State, event Event, opts triggerOptions) (TriggerResult, error) {
+	from, event = sm.normState(from), sm.normEvent(event)
+	if sm.tracer == nil {
+		return sm.triggerChain(ctx, from, event, opts)
 	}
-	if _, ok := sm.sg.events[event]; !ok {
-		return "", errors.New(fmt.Sprintf("状态机不包含事件 " , event))
+	return sm.traced(ctx, from, event, func(ctx context.Context) (TriggerResult, error) {
+		return sm.triggerChain(ctx, from, event, opts)
+	})
+}
+
+func (sm *StateMachine) triggerChain(ctx context.Context, from State, event Event, opts triggerOptions) (TriggerResult, error) {
+	if len(sm.middlewares) == 0 {
+		return sm.triggerEpsilon(ctx, from, event, opts)
+	}
+
+	var result TriggerResult
+	core := func(ctx context.Context, from State, event Event) (State, error) {
+		var err error
+		result, err = sm.triggerEpsilon(ctx, from, event, opts)
+		return result.State, err
 	}
-	if transfer, ok := sm.sg.transitions[from][event]; ok {
+	state, err := sm.chain(core)(ctx, from, event)
+	result.State = state
+	return result, err
+}
 
-		processor := sm.processor
-		// 离开状态处理，转换之前
-		if transfer.Processor != nil {
-			processor = transfer.Processor
+func (sm *StateMachine) trigger(ctx context.Context, from State, event Event, opts triggerOptions) (TriggerResult, error) {
+	result := TriggerResult{}
+	if primary, ok := sm.sg.aliases[event]; ok {
+		event = primary
+	}
+	if !sm.sg.contains(from) && !sm.permissive {
+		if sm.onUnknownState != nil {
+			sm.onUnknownState(from)
+		}
+		return result, fmt.Errorf("%w%s", ErrUnknownState, from)
+	}
+	if _, ok := sm.sg.events[event]; !ok && opts.transfer == nil && event != None && !sm.permissive {
+		if sm.onUnknownEvent != nil {
+			sm.onUnknownEvent(event)
+		}
+		return result, fmt.Errorf("%w %s", ErrUnknownEvent, event)
+	}
+	if sm.freeze && sm.sg.isTerminal(from) {
+		return result, fmt.Errorf("%w [%v --%v--> ???]", ErrTerminalState, from, event)
+	}
+	transfers, guarded, err := []*Transition{opts.transfer}, false, error(nil)
+	if opts.transfer == nil {
+		transfers, guarded, err = sm.match(ctx, from, event, opts.arg)
+	}
+	result.GuardEvaluated = guarded
+	if err != nil && sm.sg.deadState != None && errors.Is(err, ErrNoTransition) {
+		result.State, err = sm.enterDead(ctx, from, event, opts)
+		return result, err
+	}
+	if err != nil {
+		var panicErr *PanicError
+		if errors.As(err, &panicErr) {
+			result.State, result.Recovered = sm.fail(ctx, sm.callProcessor(opts), from, event, nil, err)
 		}
-		if processor == nil {
-			processor = NoopProcessor
+		return result, err
+	}
+	transfer, targets, candidates := transfers[0], []State{opts.target}, []State{opts.target}
+	if opts.target == None {
+		transfer, targets, candidates = sm.resolve(from, event, transfers)
+	}
+	result.Candidates = append([]State(nil), candidates...)
+	if sm.debugMode {
+		if err := sm.debugCandidates(from, event, candidates); err != nil {
+			sm.debugf("trigger [%s] on event [%s]: candidates %v, error %v", from, event, candidates, err)
+			return result, err
 		}
+	}
+	result.Transition = *transfer
+	result.Transition.To = append([]State(nil), transfer.To...)
+
+	for _, hook := range sm.beforeHooks {
+		hook(ctx, from, event)
+	}
+	result.State, result.Recovered, err = sm.execute(ctx, from, event, transfer, targets, opts)
+	if len(targets) == 1 {
+		result.Chosen = targets[0]
+	} else if err == nil {
+		result.Chosen = result.State
+	}
+	for _, hook := range sm.afterHooks {
+		hook(ctx, from, event, result.State, err)
+	}
+	if sm.debugMode {
+		sm.debugf("trigger [%s] on event [%s]: candidates %v, chosen [%s], guard evaluated %v, state [%s], error %v",
+			from, event, result.Candidates, result.Chosen, result.GuardEvaluated, result.State, err)
+	}
+	return result, err
+}
 
+/**
+执行匹配到的转换：OnExit、Action、OnEnter 以及错误处理，返回转换后的状态和是否进入了恢复状态
+*/
+func (sm *StateMachine) execute(ctx context.Context, from State, event Event, transfer *Transition, targets []State, opts triggerOptions) (State, bool, error) {
+	// 离开状态处理，转换之前
+	processor := opts.processor
+	if processor == nil {
+		processor = sm.transitionProcessor(transfer)
+	}
+
+	sm.debugf("exit [%s] on event [%s]", from, event)
+	err := sm.safely(func() error {
 		_ = processor.OnExit(ctx, from, event)
+		return nil
+	})
 
-		to, err := transfer.Action(ctx, from, event, transfer.To)
-		if err != nil {
-			// 转换执行错误处理
-			_ = processor.OnActionFailure(ctx, from, event, transfer.To, err)
-			return to, err
+	var to State
+	if err == nil {
+		to, err = sm.runWithRetry(ctx, transfer, from, event, targets, opts)
+		to = sm.normState(to)
+	}
+	if err == nil && to == None && len(transfer.To) > 0 {
+		if sm.nonePolicy == NoneError {
+			err = fmt.Errorf("%w [%v --%v--> %v]", ErrNoneTarget, from, event, transfer.To)
+		} else {
+			sm.debugf("stay [%s] on event [%s]: action returns none", from, event)
+			return from, false, nil
 		}
-		// TODO 返回状态不在状态表中如何处理 ？？？
+	}
+	if err == nil && to != None && sm.debugMode {
+		err = sm.debugTarget(from, event, to)
+	}
+	if err == nil && to != None {
+		err = sm.checkInvariants(ctx, to)
+	}
+	if err != nil {
+		// 转换执行错误处理
+		if state, ok := sm.fail(ctx, processor, from, event, transfer.To, err); ok {
+			return state, true, err
+		}
+		return to, false, err
+	}
+	// TODO 返回状态不在状态表中如何处理 ？？？
 
-		// 进入状态处理，转换之后
-		_ = processor.OnEnter(ctx, to)
+	// 进入状态处理，转换之后，没有目标状态的转换不进入任何状态
+	if to != None {
+		sm.debugf("enter [%s] from [%s] on event [%s]", to, from, event)
+		err = sm.safely(func() error {
+			_ = processor.OnEnter(ctx, from, event, to)
+			return nil
+		})
+	}
+	return to, false, err
+}
 
-		return to, err
+/**
+没有匹配到转换时使用的事件处理器：本次调用指定的处理器 > 状态机的处理器
+*/
+func (sm *StateMachine) callProcessor(opts triggerOptions) EventProcessorV3 {
+	if opts.processor != nil {
+		return opts.processor
 	}
-	return "", errors.New(fmt.Sprintf("没有定义状态转换事件 [%v --%v--> ???]", from, event))
+	return sm.machineProcessor()
+}
 
+/**
+转换执行错误处理，OnActionFailure 返回恢复状态或者设置了错误状态时进入该状态并返回 true
+*/
+func (sm *StateMachine) fail(ctx context.Context, processor EventProcessorV3, from State, event Event, to []State, err error) (State, bool) {
+	sm.debugf("failure %s --(%s)--> %v: %v", from, event, to, err)
+	var recovery State
+	_ = sm.safely(func() error {
+		recovery, _ = processor.OnActionFailure(ctx, from, event, to, err)
+		return nil
+	})
+	if recovery = sm.normState(recovery); recovery == None {
+		recovery = sm.sg.errorState
+	}
+	if recovery == None {
+		return None, false
+	}
+	sm.debugf("enter recovery state [%s] from [%s] on event [%s]", recovery, from, event)
+	_ = sm.safely(func() error {
+		return processor.OnEnter(ctx, from, event, recovery)
+	})
+	return recovery, true
 }
 
 /**
 输出图的显示内容
-输出 PlantUML 显示 URL
+输出 PlantUML 显示 URL，不会打开浏览器，需要时调用 Open
 */
 func (sm *StateMachine) Show() string {
-	return sm.sg.show()
+	return showEncoded(sm.sg.cachedPlantUML())
+}
+
+/**
+在浏览器中打开状态图的在线图片地址
+*/
+func (sm *StateMachine) Open() error {
+	_, plantText := sm.sg.cachedPlantUML()
+	return open(plantUMLServer + "/img/~1" + plantText)
+}
+
+/**
+只显示包含标签 tag 的转换和这些转换涉及的状态
+*/
+func (sm *StateMachine) ShowFiltered(tag string) string {
+	keep := func(transfer *Transition) bool {
+		return containsTag(transfer.Tags, tag)
+	}
+	return showPlantUML(plantUMLDocument(sm.sg.plantUMLTheme(), sm.sg.plantUMLBlock("rootGraph", "", keep)))
+}
+
+/**
+在一个 PlantUML 图中并排显示多个状态机，每个状态机是一个以名称为标签的组合状态
+样式和方向使用第一个状态机的主题
+*/
+func ShowAll(machines ...*StateMachine) string {
+	return showPlantUML(plantUMLAll(machines...))
+}
+
+func plantUMLAll(machines ...*StateMachine) string {
+	if len(machines) == 0 {
+		return plantUMLDocument(DefaultPlantUMLTheme)
+	}
+	var blocks []string
+	for i, sm := range machines {
+		blocks = append(blocks, sm.sg.plantUMLBlock(fmt.Sprintf("graph%d", i+1), fmt.Sprintf("g%d_", i+1), nil))
+	}
+	return plantUMLDocument(machines[0].sg.plantUMLTheme(), blocks...)
+}
+
+/**
+状态机的文本摘要：名称、状态数量和排序后的状态转换，不会打开浏览器
+*/
+func (sm *StateMachine) String() string {
+	var lines []string
+	sm.EachTransition(func(from State, event Event, to State) bool {
+		lines = append(lines, fmt.Sprintf("%s --(%s)--> %s", from, event, to))
+		return true
+	})
+	sort.Strings(lines)
+	header := fmt.Sprintf("StateMachine[%s] %d states", sm.sg.name, len(sm.sg.states))
+	return strings.Join(append([]string{header}, lines...), "\n")
 }
 
 func (transfer Transition) String() string {
@@ -234,33 +1029,95 @@ func (transfer Transition) String() string {
 }
 
 /**
-输出图的显示内容
-输出 PlantUML 显示 URL
+输出 PlantUML 脚本和在线生成图标地址
+*/
+func showPlantUML(raw string) string {
+	return showEncoded(raw, encode(raw))
+}
+
+func showEncoded(raw, plantText string) string {
+	// 输出 plantUml 和 在线生成图标地址
+	imgUrl := plantUMLServer + "/img/~1" + plantText
+	svgUrl := plantUMLServer + "/svg/~1" + plantText
+	format := "\nPlantUml Script:\n%s\n\nOnline Graph:\n\tImg: %s\n\tSvg: %s"
+	return fmt.Sprintf(format, raw, imgUrl, svgUrl)
+}
+
+/**
+PlantUML 在线服务地址
+*/
+var plantUMLServer = "https://www.plantuml.com/plantuml"
+
+/**
+生成 PlantUML 脚本
+*/
+func (sg *stateGraph) plantUML() string {
+	return plantUMLDocument(sg.plantUMLTheme(), sg.plantUMLBlock("rootGraph", "", nil))
+}
+
+/**
+生成状态机的 PlantUML 组合状态块，块名为 alias，状态 id 加上 prefix 前缀以区分不同状态机的同名状态
+keep 不为 nil 时只显示 keep 返回 true 的转换和这些转换涉及的状态
 */
-func (sg *stateGraph) show() string {
+func (sg *stateGraph) plantUMLBlock(alias, prefix string, keep func(*Transition) bool) string {
 	// 头部信息
+	theme := sg.plantUMLTheme()
 	title := ""
 	smType := "DFA"
 	if sg.name != "" {
 		title = "<b>[" + sg.name + "]</b> "
 	}
 
+	// 过滤后显示的状态
+	shown := func(State) bool { return true }
+	if keep != nil {
+		used := map[State]bool{}
+		for _, events := range sg.transitions {
+			for _, transfers := range events {
+				for _, transfer := range transfers {
+					if keep(transfer) {
+						used[transfer.From] = true
+						for _, to := range transfer.To {
+							used[to] = true
+						}
+					}
+				}
+			}
+		}
+		for from, t := range sg.timeouts {
+			if keep(t.transfer) {
+				used[from], used[t.transfer.To[0]] = true, true
+			}
+		}
+		shown = func(state State) bool { return used[state] }
+	}
+
 	// 状态的定义
 	var stateLines []string
-	for state, desc := range sg.states {
+	for _, state := range sortedStates(sg.states) {
+		desc := sg.states[state]
+		if !shown(state) {
+			continue
+		}
 		stateLine := string(state)
 
 		nextNFA := ""
-		for _, transfer := range sg.transitions[state] {
-			if len(transfer.To) > 1 {
+		for _, transfers := range sg.transitions[state] {
+			if distinctTargets(transfers) > 1 {
 				nextNFA = "<<NFA>>"
 			}
 		}
+		if nextNFA == "" && theme.TerminalColor != "" && sg.isTerminal(state) {
+			nextNFA = "<<Terminal>>"
+		}
 
+		if color := sg.stateColor(state); color != "" {
+			nextNFA = strings.TrimSpace(nextNFA + " " + color)
+		}
 		if desc != "" {
-			stateLine = fmt.Sprintf(`state "%s" as %s %s :%s`, state, state, nextNFA, desc)
+			stateLine = fmt.Sprintf(`state "%s" as %s%s %s :%s`, state, prefix, state, nextNFA, desc)
 		} else {
-			stateLine = fmt.Sprintf(`state "%s" as %s %s`, state, state, nextNFA)
+			stateLine = fmt.Sprintf(`state "%s" as %s%s %s`, state, prefix, state, nextNFA)
 		}
 
 		stateLines = append(stateLines, stateLine)
@@ -272,79 +1129,157 @@ func (sg *stateGraph) show() string {
 	// 开始状态处理
 	if sg.start != nil && len(sg.start) > 0 {
 		for _, event := range sg.start {
+			if !shown(event) {
+				continue
+			}
 			transferLines = append(transferLines,
-				fmt.Sprintf("%s --> %s",
-					Start,
-					event))
+				fmt.Sprintf("%s --> %s%s",
+					pseudoState,
+					prefix, event))
 		}
 	}
 	// 处理中间状态转换
-	for from, events := range sg.transitions {
-		for event, transfer := range events {
-			eventString := fmt.Sprintf("%s",event)
-			if len(transfer.To) > 1 {
+	for _, from := range sg.sortedFroms() {
+		events := sg.transitions[from]
+		for _, event := range sortedTransitionEvents(events) {
+			transfers := events[event]
+			var kept []*Transition
+			for _, transfer := range transfers {
+				if keep != nil && !keep(transfer) {
+					continue
+				}
+				kept = append(kept, transfer)
+			}
+			if distinctTargets(kept) > 1 {
 				smType = "NFA"
 			}
-			if eventString != "" {
-				desc := sg.events[event]
-				eventString = "(" + eventString + ") "
-				if desc != "" {
-					eventString = eventString + fmt.Sprintf("%s",desc)
+			for _, transfer := range kept {
+				eventString := sg.eventLabel(event)
+				if eventString != "" {
+					desc := sg.events[event]
+					if transfer.Desc != "" {
+						desc = transfer.Desc
+					}
+					eventString = "(" + eventString + ") "
+					if desc != "" {
+						eventString = eventString + fmt.Sprintf("%s",desc)
+					}
+					if len(transfer.To) > 1 {
+						eventString = "<font color=red><b>" + eventString + "</b></font>"
+					}
 				}
-				if len(transfer.To) > 1 {
-					eventString = "<font color=red><b>" + eventString + "</b></font>"
+				// plantUml 格式
+				if eventString != "" {
+					eventString = ": " + eventString
 				}
-			}
-			// plantUml 格式
-			if eventString != "" {
-				eventString = ": " + eventString
-			}
 
-			for j := 0; j < len(transfer.To); j++ {
-				to := transfer.To[j]
-				transferLines = append(transferLines,
-					fmt.Sprintf("%s --> %s %s",
-						from,
-						to,
-						eventString))
+				probabilities := transfer.probabilities()
+				for j := 0; j < len(transfer.To); j++ {
+					to := transfer.To[j]
+					label := eventString
+					if probabilities != nil {
+						if label == "" {
+							label = ":"
+						}
+						label = fmt.Sprintf("%s p=%.2f", label, probabilities[j])
+					}
+					arrow := "-->"
+					if sg.isDisabled(from, event) {
+						arrow = "-[dashed]->"
+					}
+					transferLines = append(transferLines,
+						fmt.Sprintf("%s %s %s %s",
+							plantUMLID(prefix, from), arrow,
+							plantUMLID(prefix, to),
+							label))
+				}
 			}
 		}
 	}
+	// 定时转换
+	for _, from := range sg.timeoutStates() {
+		transfer := sg.timeouts[from].transfer
+		if keep != nil && !keep(transfer) {
+			continue
+		}
+		transferLines = append(transferLines,
+			fmt.Sprintf("%s%s --> %s : %s", prefix, from, plantUMLID(prefix, transfer.To[0]), transfer.Event))
+	}
 	// 结束状态处理
 	if sg.end != nil && len(sg.end) > 0 {
 		for _, event := range sg.end {
+			if !shown(event) {
+				continue
+			}
 			transferLines = append(transferLines,
-				fmt.Sprintf("%s --> %s",
-					event,
-					End))
+				fmt.Sprintf("%s%s --> %s",
+					prefix, event,
+					pseudoState))
 		}
 	}
 	transitionsDef := strings.Join(transferLines, "\n")
 
-	// 生成 plantUml script
-	raw := `
-	@startuml
-	skinparam state {
-	  BackgroundColor<<NFA>> Red
-	}
-	State "<font color=red><b><<%s>></b></font>\n` + title + `State Graph" as rootGraph {
+	raw := `State "<font color=red><b><<%s>></b></font>\n` + title + theme.Title + `" as ` + alias + ` {
 		%s
 
 		%s
+	}`
+	return fmt.Sprintf(raw, smType, statesDef, transitionsDef)
+}
+
+/**
+状态在 PlantUML 中的 id，开始和结束状态显示为 [*]，不加前缀
+*/
+func plantUMLID(prefix string, state State) string {
+	if state == Start || state == End {
+		return pseudoState
+	}
+	if state == None {
+		return string(state)
 	}
+	return prefix + string(state)
+}
+
+/**
+生成完整的 PlantUML 脚本，样式和方向使用 theme
+*/
+func plantUMLDocument(theme PlantUMLTheme, blocks ...string) string {
+	skin := "BackgroundColor<<NFA>> " + theme.NFAColor
+	if theme.TerminalColor != "" {
+		skin += "\n\t  BackgroundColor<<Terminal>> " + theme.TerminalColor
+	}
+	direction := ""
+	if directive := theme.directive(); directive != "" {
+		direction = "\n\t" + directive
+	}
+	return `
+	@startuml` + direction + `
+	skinparam state {
+	  ` + skin + `
+	}
+	` + strings.Join(blocks, "\n\t") + `
 
 	@enduml
 	`
-	raw = fmt.Sprintf(raw, smType, statesDef, transitionsDef)
+}
 
-	// 输出 plantUml 和 在线生成图标地址
-	plantText := encode(raw)
-	imgUrl := "https://www.plantuml.com/plantuml/img/~1" + plantText
-	svgUrl := "https://www.plantuml.com/plantuml/svg/~1" + plantText
-	format := "\nPlantUml Script:\n%s\n\nOnline Graph:\n\tImg: %s\n\tSvg: %s"
-	open(imgUrl)
-	return fmt.Sprintf(format, raw, imgUrl, svgUrl)
+/**
+事件在图中的显示名称，主事件和别名合并显示
+*/
+func (sg *stateGraph) eventLabel(event Event) string {
+	var aliases []string
+	for alias, primary := range sg.aliases {
+		if primary == event {
+			aliases = append(aliases, string(alias))
+		}
+	}
+	if len(aliases) == 0 || event == None {
+		return string(event)
+	}
+	sort.Strings(aliases)
+	return strings.Join(append([]string{string(event)}, aliases...), " | ")
 }
+
 func open(url string) error {
     var cmd string
     var args []string
@@ -12,7 +12,7 @@ func TestNew(t *testing.T) {
 		name string
 		want *StateMachine
 	}{
-		{"new", &StateMachine{processor: nil, sg: &stateGraph{transitions: map[State]map[Event]*Transition{}}}},
+		{"new", &StateMachine{processor: nil, sg: &stateGraph{transitions: map[State]map[Event][]*Transition{}}}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -52,11 +52,11 @@ func Test_stateMachine_Show(t *testing.T) {
 				"Execute": "",
 			},
 			[]Transition{
-				{Start, "Start", []State{"s1"}, NoopAction, nil},
-				{Start, None, []State{"s2"}, NoopAction, nil},
-				{"s2", "Execute", []State{End, "44"}, NoopAction, nil},
-				{"s2", "Execute", []State{End, "33"}, NoopAction, NoopProcessor},
-				{"s2", "SS", []State{End, "11", "22"}, NoopAction, nil},
+				{From: Start, Event: "Start", To: []State{"s1"}, Action: NoopAction},
+				{From: Start, Event: None, To: []State{"s2"}, Action: NoopAction},
+				{From: "s2", Event: "Execute", To: []State{End, "44"}, Action: NoopAction},
+				{From: "s2", Event: "Execute", To: []State{End, "33"}, Action: NoopAction, Processor: NoopProcessor},
+				{From: "s2", Event: "SS", To: []State{End, "11", "22"}, Action: NoopAction},
 			},
 		}, `state "s2" as s2 <<NFA>>`},
 	}
@@ -89,7 +89,7 @@ func Test_stateMachine_States(t *testing.T) {
 		{"Has States", args{StatesDef{"a": "a", "b": "b"}}, &StateMachine{
 			sg: &stateGraph{
 				states:      StatesDef{"a": "a", "b": "b"},
-				transitions: map[State]map[Event]*Transition{},
+				transitions: map[State]map[Event][]*Transition{},
 			}}},
 	}
 	for _, tt := range tests {
@@ -116,7 +116,7 @@ func Test_stateMachine_Events(t *testing.T) {
 		{"Has Events", args{EventsDef{"a": "a", "b": "b"}}, &StateMachine{
 			sg: &stateGraph{
 				events:      EventsDef{"a": "a", "b": "b"},
-				transitions: map[State]map[Event]*Transition{},
+				transitions: map[State]map[Event][]*Transition{},
 			}}},
 	}
 	for _, tt := range tests {
@@ -132,8 +132,8 @@ func Test_stateMachine_Events(t *testing.T) {
 func Test_stateMachine_Transitions(t *testing.T) {
 	// 数据定义
 	ts := []Transition{
-		{Start, None, []State{End}, NoopAction, nil},
-		{Start, "event1", []State{End, "test2"}, NoopAction, nil},
+		{From: Start, Event: None, To: []State{End}, Action: NoopAction},
+		{From: Start, Event: "event1", To: []State{End, "test2"}, Action: NoopAction},
 	}
 
 	// table
@@ -159,3 +159,22 @@ func Test_stateMachine_Transitions(t *testing.T) {
 		})
 	}
 }
+
+func Test_removeRepByMap(t *testing.T) {
+	tests := []struct {
+		name string
+		args []State
+		want []State
+	}{
+		{"Empty", nil, []State{}},
+		{"Duplicates And Empty", []State{"A", "", "A", "B"}, []State{"A", "B"}},
+		{"Keep Order", []State{"B", "A", "B"}, []State{"B", "A"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := removeRepByMap(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("removeRepByMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
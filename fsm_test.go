@@ -8,6 +8,7 @@ import (
 	"github.com/threeq/gofsm"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -69,7 +70,7 @@ func Test_stateMachine_Trigger(t *testing.T) {
 				states: gofsm.StatesDef{"s1": "s1", "s2": "s2", "s3": "s3"},
 				events: gofsm.EventsDef{"e1": "e1", "e2": "e2",},
 				transitions: []gofsm.Transition{
-					{"s1", "e1", []gofsm.State{"s2"}, gofsm.NoopAction, nil},
+					{From: "s1", Event: "e1", To: []gofsm.State{"s2"}, Action: gofsm.NoopAction},
 				},
 				processor: gofsm.NoopProcessor},
 			args{nil, "s1", "e2"},
@@ -80,7 +81,7 @@ func Test_stateMachine_Trigger(t *testing.T) {
 				states: gofsm.StatesDef{"s1": "s1", "s2": "s2", "s3": "s3"},
 				events: gofsm.EventsDef{"e1": "e1", "e2": "e2",},
 				transitions: []gofsm.Transition{
-					{"s1", "e1", nil, gofsm.NoopAction, nil},
+					{From: "s1", Event: "e1", Action: gofsm.NoopAction},
 				},
 				processor: gofsm.NoopProcessor},
 			args{nil, "s1", "e1"},
@@ -90,7 +91,7 @@ func Test_stateMachine_Trigger(t *testing.T) {
 				states: gofsm.StatesDef{"s1": "s1", "s2": "s2", "s3": "s3"},
 				events: gofsm.EventsDef{"e1": "e1", "e2": "e2",},
 				transitions: []gofsm.Transition{
-					{"s1", "e1", []gofsm.State{"s2"}, gofsm.NoopAction, nil},
+					{From: "s1", Event: "e1", To: []gofsm.State{"s2"}, Action: gofsm.NoopAction},
 				},
 				processor: gofsm.NoopProcessor},
 			args{nil, "s1", "e1"},
@@ -100,9 +101,9 @@ func Test_stateMachine_Trigger(t *testing.T) {
 				states: gofsm.StatesDef{"s1": "s1", "s2": "s2", "s3": "s3"},
 				events: gofsm.EventsDef{"e1": "e1", "e2": "e2",},
 				transitions: []gofsm.Transition{
-					{"s1", "e1", []gofsm.State{"s2"}, gofsm.NoopAction, nil},
-					{"s1", "e2", []gofsm.State{"s3"}, gofsm.NoopAction, nil},
-					{"s1", "e2", []gofsm.State{"s3"}, gofsm.NoopAction, nil},
+					{From: "s1", Event: "e1", To: []gofsm.State{"s2"}, Action: gofsm.NoopAction},
+					{From: "s1", Event: "e2", To: []gofsm.State{"s3"}, Action: gofsm.NoopAction},
+					{From: "s1", Event: "e2", To: []gofsm.State{"s3"}, Action: gofsm.NoopAction},
 				},
 				processor: gofsm.NoopProcessor},
 			args{nil, "s1", "e2"},
@@ -112,9 +113,9 @@ func Test_stateMachine_Trigger(t *testing.T) {
 				states: gofsm.StatesDef{"s1": "s1", "s2": "s2", "s3": "s3"},
 				events: gofsm.EventsDef{"e1": "e1", "e2": "e2",},
 				transitions: []gofsm.Transition{
-					{"s1", "e1", []gofsm.State{"s2"}, gofsm.NoopAction, nil},
-					{"s1", "e2", []gofsm.State{"s3"}, gofsm.NoopAction, nil},
-					{"s1", "e2", []gofsm.State{"s3"}, gofsm.NoopAction, nil},
+					{From: "s1", Event: "e1", To: []gofsm.State{"s2"}, Action: gofsm.NoopAction},
+					{From: "s1", Event: "e2", To: []gofsm.State{"s3"}, Action: gofsm.NoopAction},
+					{From: "s1", Event: "e2", To: []gofsm.State{"s3"}, Action: gofsm.NoopAction},
 				},
 				processor: nil},
 			args{nil, "s1", "e2"},
@@ -124,9 +125,9 @@ func Test_stateMachine_Trigger(t *testing.T) {
 				states: gofsm.StatesDef{"s1": "s1", "s2": "s2", "s3": "s3"},
 				events: gofsm.EventsDef{"e1": "e1", "e2": "e2",},
 				transitions: []gofsm.Transition{
-					{"s1", "e1", []gofsm.State{"s2"}, gofsm.NoopAction, nil},
-					{"s1", "e2", []gofsm.State{"s3"}, gofsm.NoopAction, &CustomProcessor{}},
-					{"s1", "e2", []gofsm.State{"s3"}, gofsm.NoopAction, nil},
+					{From: "s1", Event: "e1", To: []gofsm.State{"s2"}, Action: gofsm.NoopAction},
+					{From: "s1", Event: "e2", To: []gofsm.State{"s3"}, Action: gofsm.NoopAction, Processor: &CustomProcessor{}},
+					{From: "s1", Event: "e2", To: []gofsm.State{"s3"}, Action: gofsm.NoopAction},
 				},
 				processor: gofsm.NoopProcessor},
 			args{nil, "s1", "e2"},
@@ -136,11 +137,11 @@ func Test_stateMachine_Trigger(t *testing.T) {
 				states: gofsm.StatesDef{"s1": "s1", "s2": "s2", "s3": "s3"},
 				events: gofsm.EventsDef{"e1": "e1", "e2": "e2",},
 				transitions: []gofsm.Transition{
-					{"s1", "e1", []gofsm.State{"s2"}, gofsm.NoopAction, nil},
-					{"s1", "e2", []gofsm.State{"s3"}, func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (state gofsm.State, e error) {
+					{From: "s1", Event: "e1", To: []gofsm.State{"s2"}, Action: gofsm.NoopAction},
+					{From: "s1", Event: "e2", To: []gofsm.State{"s3"}, Action: func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (state gofsm.State, e error) {
 						return "", errors.New("action error")
-					}, nil},
-					{"s1", "e2", []gofsm.State{"s3"}, gofsm.NoopAction, nil},
+					}},
+					{From: "s1", Event: "e2", To: []gofsm.State{"s3"}, Action: gofsm.NoopAction},
 				},
 				processor: gofsm.NoopProcessor},
 			args{nil, "s1", "e2"},
@@ -150,11 +151,11 @@ func Test_stateMachine_Trigger(t *testing.T) {
 				states: gofsm.StatesDef{"s1": "s1", "s2": "s2", "s3": "s3"},
 				events: gofsm.EventsDef{"e1": "e1", "e2": "e2",},
 				transitions: []gofsm.Transition{
-					{"s1", "e1", []gofsm.State{"s2"}, gofsm.NoopAction, nil},
-					{"s1", "e2", []gofsm.State{"s3"}, func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (state gofsm.State, e error) {
+					{From: "s1", Event: "e1", To: []gofsm.State{"s2"}, Action: gofsm.NoopAction},
+					{From: "s1", Event: "e2", To: []gofsm.State{"s3"}, Action: func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (state gofsm.State, e error) {
 						return "", errors.New("action error")
-					}, &CustomProcessor{}},
-					{"s1", "e2", []gofsm.State{"s3"}, gofsm.NoopAction, nil},
+					}, Processor: &CustomProcessor{}},
+					{From: "s1", Event: "e2", To: []gofsm.State{"s3"}, Action: gofsm.NoopAction},
 				},
 				processor: gofsm.NoopProcessor},
 			args{nil, "s1", "e2"},
@@ -253,16 +254,16 @@ func TestStateMachine_Example_Order(t *testing.T) {
 			CancelEvent:          "去掉订单",
 		}).
 		Transitions([]gofsm.Transition{
-			{Start, CreateEvent, []gofsm.State{WaitPay}, doAction, nil},
-			{WaitPay, PayEvent, []gofsm.State{Paying}, doAction, nil},
-			{WaitPay, CancelEvent, []gofsm.State{Canceled}, doAction, nil},
-			{Paying, PaySuccessEvent, []gofsm.State{WaitSend}, doAction, nil},
-			{Paying, PayFailureEvent, []gofsm.State{PayFailure}, doAction, nil},
-			{PayFailure, PayEvent, []gofsm.State{Paying}, doAction, nil},
-			{PayFailure, CancelEvent, []gofsm.State{Canceled}, doAction, nil},
-			{WaitSend, SendStartEvent, []gofsm.State{Sending}, doAction, nil},
-			{Sending, SendEndEvent, []gofsm.State{WaitConfirm}, doAction, nil},
-			{WaitConfirm, ConfirmReceivedEvent, []gofsm.State{Received}, doAction, nil},
+			{From: Start, Event: CreateEvent, To: []gofsm.State{WaitPay}, Action: doAction},
+			{From: WaitPay, Event: PayEvent, To: []gofsm.State{Paying}, Action: doAction},
+			{From: WaitPay, Event: CancelEvent, To: []gofsm.State{Canceled}, Action: doAction},
+			{From: Paying, Event: PaySuccessEvent, To: []gofsm.State{WaitSend}, Action: doAction},
+			{From: Paying, Event: PayFailureEvent, To: []gofsm.State{PayFailure}, Action: doAction},
+			{From: PayFailure, Event: PayEvent, To: []gofsm.State{Paying}, Action: doAction},
+			{From: PayFailure, Event: CancelEvent, To: []gofsm.State{Canceled}, Action: doAction},
+			{From: WaitSend, Event: SendStartEvent, To: []gofsm.State{Sending}, Action: doAction},
+			{From: Sending, Event: SendEndEvent, To: []gofsm.State{WaitConfirm}, Action: doAction},
+			{From: WaitConfirm, Event: ConfirmReceivedEvent, To: []gofsm.State{Received}, Action: doAction},
 		}...)
 
 	println(orderStateMachine.Show())
@@ -285,11 +286,11 @@ func BenchmarkStateMachine_Trigger(b *testing.B) {
 
 	states := gofsm.StatesDef{}
 	for i := 0; i < 100; i++ {
-		states["s"+strconv.Itoa(i)] = "ss " + strconv.Itoa(i)
+		states[gofsm.State("s"+strconv.Itoa(i))] = "ss " + strconv.Itoa(i)
 	}
 	events := gofsm.EventsDef{}
 	for i := 0; i < 100; i++ {
-		events["e"+strconv.Itoa(i)] = "ee " + strconv.Itoa(i)
+		events[gofsm.Event("e"+strconv.Itoa(i))] = "ee " + strconv.Itoa(i)
 	}
 
 	sm := gofsm.New("").
@@ -301,9 +302,9 @@ func BenchmarkStateMachine_Trigger(b *testing.B) {
 		from := gofaker.NaturalN(0, 100)
 		to := gofaker.NaturalN(0, 100)
 		sm.Transitions(gofsm.Transition{
-			From:   "s" + strconv.Itoa(from),
-			Event:  "e" + strconv.Itoa(n),
-			To:     []gofsm.State{"s" + strconv.Itoa(to)},
+			From:   gofsm.State("s" + strconv.Itoa(from)),
+			Event:  gofsm.Event("e" + strconv.Itoa(n)),
+			To:     []gofsm.State{gofsm.State("s" + strconv.Itoa(to))},
 			Action: gofsm.NoopAction})
 	}
 
@@ -312,6 +313,886 @@ func BenchmarkStateMachine_Trigger(b *testing.B) {
 	b.StartTimer() //重新开始时间
 	for i := 0; i < b.N; i++ {
 		s := strconv.Itoa(i % 30)
-		_, _ = sm.Trigger(context.TODO(), "s"+s, "e"+s)
+		_, _ = sm.Trigger(context.TODO(), gofsm.State("s"+s), gofsm.Event("e"+s))
+	}
+}
+
+func BenchmarkStateMachine_Trigger_Large(b *testing.B) {
+	const size = 5000
+	newMachine := func() *gofsm.StateMachine {
+		states, events := gofsm.StatesDef{}, gofsm.EventsDef{}
+		transitions := make([]gofsm.Transition, 0, size)
+		for n := 0; n < size; n++ {
+			from, to := gofsm.State("s"+strconv.Itoa(n)), gofsm.State("s"+strconv.Itoa((n+1)%size))
+			event := gofsm.Event("e" + strconv.Itoa(n%50))
+			states[from], events[event] = "", ""
+			transitions = append(transitions, gofsm.Transition{From: from, Event: event, To: []gofsm.State{to}, Action: gofsm.NoopAction})
+		}
+		return gofsm.New("").States(states).Events(events).Processor(gofsm.NoopProcessor).Transitions(transitions...)
+	}
+
+	tests := []struct {
+		name string
+		sm   *gofsm.StateMachine
+	}{
+		{"Unsealed", newMachine()},
+		{"Sealed", newMachine().Seal()},
+	}
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				n := i % size
+				_, _ = tt.sm.Trigger(context.TODO(), gofsm.State("s"+strconv.Itoa(n)), gofsm.Event("e"+strconv.Itoa(n%50)))
+			}
+		})
+	}
+}
+
+func BenchmarkStateMachine_Transitions_Merge(b *testing.B) {
+	const size = 5000
+	transitions := make([]gofsm.Transition, 0, size)
+	for n := 0; n < size; n++ {
+		transitions = append(transitions, gofsm.Transition{From: "s", Event: "e", To: []gofsm.State{gofsm.State("t" + strconv.Itoa(n))}, Action: gofsm.NoopAction})
+	}
+	b.Run("Batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			gofsm.New("").Transitions(append([]gofsm.Transition(nil), transitions...)...).Seal()
+		}
+	})
+	b.Run("Single", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sm := gofsm.New("")
+			for _, transfer := range transitions {
+				sm.Transitions(transfer)
+			}
+			sm.Seal()
+		}
+	})
+}
+
+type recordLogger struct {
+	lines []string
+}
+
+func (l *recordLogger) Logf(format string, args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func TestStateMachine_Logger(t *testing.T) {
+	failure := func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (gofsm.State, error) {
+		return "", errors.New("action error")
+	}
+	tests := []struct {
+		name  string
+		event gofsm.Event
+		want  []string
+	}{
+		{"Success", "e1", []string{"exit [s1] on event [e1]", "enter [s2] from [s1] on event [e1]"}},
+		{"Failure", "e2", []string{"exit [s1] on event [e2]", "failure s1 --(e2)--> [s3]: action error"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &recordLogger{}
+			sm := gofsm.New("logger").
+				States(gofsm.StatesDef{"s1": "s1", "s2": "s2", "s3": "s3"}).
+				Events(gofsm.EventsDef{"e1": "e1", "e2": "e2"}).
+				Transitions(
+					gofsm.Transition{From: "s1", Event: "e1", To: []gofsm.State{"s2"}, Action: gofsm.NoopAction},
+					gofsm.Transition{From: "s1", Event: "e2", To: []gofsm.State{"s3"}, Action: failure},
+				).
+				Logger(logger)
+			_, _ = sm.Trigger(context.TODO(), "s1", tt.event)
+			if len(logger.lines) != len(tt.want) {
+				t.Fatalf("Logger lines = %v, want %v", logger.lines, tt.want)
+			}
+			for i, want := range tt.want {
+				if !strings.HasSuffix(logger.lines[i], want) || !strings.HasPrefix(logger.lines[i], "[DEBUG] gofsm(logger): ") {
+					t.Errorf("Logger line %d = %q, want suffix %q", i, logger.lines[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestStateMachine_AliasEvent(t *testing.T) {
+	sm := gofsm.New("alias").
+		States(gofsm.StatesDef{"review": "审核中", "approved": "已通过"}).
+		Events(gofsm.EventsDef{"approve": "通过"}).
+		Transitions(gofsm.Transition{From: "review", Event: "approve", To: []gofsm.State{"approved"}, Action: gofsm.NoopAction}).
+		AliasEvent("approve", "approve_l1", "approve_l2")
+
+	tests := []struct {
+		name    string
+		event   gofsm.Event
+		want    gofsm.State
+		wantErr bool
+	}{
+		{"Primary", "approve", "approved", false},
+		{"Alias 1", "approve_l1", "approved", false},
+		{"Alias 2", "approve_l2", "approved", false},
+		{"Not Alias", "approve_l3", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sm.Trigger(context.TODO(), "review", tt.event)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("StateMachine.Trigger() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("StateMachine.Trigger() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := plantUMLScript(t, sm); !strings.Contains(got, "review --> approved : (approve | approve_l1 | approve_l2) 通过") {
+		t.Errorf("PlantUML script = %v, want grouped events", got)
+	}
+}
+
+func TestStateMachine_Trigger_Priority(t *testing.T) {
+	premium := func(ctx context.Context, from gofsm.State, event gofsm.Event) (bool, error) {
+		return ctx.Value("premium") == true, nil
+	}
+	broken := func(ctx context.Context, from gofsm.State, event gofsm.Event) (bool, error) {
+		return false, errors.New("guard error")
+	}
+	never := func(ctx context.Context, from gofsm.State, event gofsm.Event) (bool, error) {
+		return false, nil
+	}
+	states := gofsm.StatesDef{"order": "", "premium": "", "standard": "", "manual": ""}
+	events := gofsm.EventsDef{"ship": "", "check": ""}
+
+	tests := []struct {
+		name        string
+		transitions []gofsm.Transition
+		ctx         context.Context
+		event       gofsm.Event
+		want        gofsm.State
+		wantErr     bool
+	}{
+		{"Guard Pass",
+			[]gofsm.Transition{
+				{From: "order", Event: "ship", To: []gofsm.State{"standard"}, Action: gofsm.NoopAction},
+				{From: "order", Event: "ship", To: []gofsm.State{"premium"}, Action: gofsm.NoopAction, Guard: premium, Priority: 10},
+			},
+			context.WithValue(context.TODO(), "premium", true), "ship", "premium", false},
+		{"Guard Fallback",
+			[]gofsm.Transition{
+				{From: "order", Event: "ship", To: []gofsm.State{"standard"}, Action: gofsm.NoopAction},
+				{From: "order", Event: "ship", To: []gofsm.State{"premium"}, Action: gofsm.NoopAction, Guard: premium, Priority: 10},
+			},
+			context.TODO(), "ship", "standard", false},
+		{"Priority Order",
+			[]gofsm.Transition{
+				{From: "order", Event: "ship", To: []gofsm.State{"standard"}, Action: gofsm.NoopAction, Priority: 1},
+				{From: "order", Event: "ship", To: []gofsm.State{"manual"}, Action: gofsm.NoopAction, Priority: 5},
+			},
+			context.TODO(), "ship", "manual", false},
+		{"Same Priority Keeps Order",
+			[]gofsm.Transition{
+				{From: "order", Event: "ship", To: []gofsm.State{"premium"}, Action: gofsm.NoopAction, Guard: premium},
+				{From: "order", Event: "ship", To: []gofsm.State{"manual"}, Action: gofsm.NoopAction, Guard: premium},
+			},
+			context.WithValue(context.TODO(), "premium", true), "ship", "premium", false},
+		{"No Guard Pass",
+			[]gofsm.Transition{
+				{From: "order", Event: "ship", To: []gofsm.State{"premium"}, Action: gofsm.NoopAction, Guard: never},
+			},
+			context.TODO(), "ship", "", true},
+		{"Guard Error",
+			[]gofsm.Transition{
+				{From: "order", Event: "ship", To: []gofsm.State{"premium"}, Action: gofsm.NoopAction, Guard: broken, Priority: 1},
+				{From: "order", Event: "ship", To: []gofsm.State{"standard"}, Action: gofsm.NoopAction},
+			},
+			context.TODO(), "ship", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := gofsm.New("").States(states).Events(events).Transitions(tt.transitions...)
+			got, err := sm.Trigger(tt.ctx, "order", tt.event)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("StateMachine.Trigger() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("StateMachine.Trigger() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStateMachine_ErrorState(t *testing.T) {
+	failure := func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (gofsm.State, error) {
+		return "", errors.New("action error")
+	}
+	tests := []struct {
+		name       string
+		errorState gofsm.State
+		event      gofsm.Event
+		want       gofsm.State
+		wantEnter  []gofsm.State
+		wantErr    bool
+	}{
+		{"No Error State", gofsm.None, "fail", "", nil, true},
+		{"Error State", "error", "fail", "error", []gofsm.State{"error"}, true},
+		{"Action Success", "error", "ok", "done", []gofsm.State{"done"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := &enterProcessor{}
+			sm := gofsm.New("").
+				States(gofsm.StatesDef{"doing": "", "done": "", "error": ""}).
+				Events(gofsm.EventsDef{"ok": "", "fail": ""}).
+				Transitions(
+					gofsm.Transition{From: "doing", Event: "ok", To: []gofsm.State{"done"}, Action: gofsm.NoopAction},
+					gofsm.Transition{From: "doing", Event: "fail", To: []gofsm.State{"done"}, Action: failure},
+				).
+				ErrorState(tt.errorState).
+				Processor(processor)
+			got, err := sm.Trigger(context.TODO(), "doing", tt.event)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("StateMachine.Trigger() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("StateMachine.Trigger() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(processor.entered, tt.wantEnter) {
+				t.Errorf("OnEnter = %v, want %v", processor.entered, tt.wantEnter)
+			}
+		})
+	}
+}
+
+type enterFromProcessor struct {
+	gofsm.DefaultProcessor
+	entered []string
+}
+
+func (p *enterFromProcessor) OnEnter(ctx context.Context, from gofsm.State, event gofsm.Event, to gofsm.State) error {
+	p.entered = append(p.entered, fmt.Sprintf("%s-%s-%s", from, event, to))
+	return nil
+}
+
+func TestStateMachine_ProcessorV2(t *testing.T) {
+	newMachine := func() *gofsm.StateMachine {
+		return gofsm.New("").
+			States(gofsm.StatesDef{"a": "", "b": "", "c": ""}).
+			Events(gofsm.EventsDef{"e1": "", "e2": ""})
+	}
+	t.Run("Machine Processor", func(t *testing.T) {
+		processor := &enterFromProcessor{}
+		sm := newMachine().
+			Transitions(
+				gofsm.Transition{From: "a", Event: "e1", To: []gofsm.State{"b"}, Action: gofsm.NoopAction},
+				gofsm.Transition{From: "c", Event: "e1", To: []gofsm.State{"b"}, Action: gofsm.NoopAction},
+			).
+			ProcessorV2(processor)
+		_, _ = sm.Trigger(context.TODO(), "a", "e1")
+		_, _ = sm.Trigger(context.TODO(), "c", "e1")
+		if want := []string{"a-e1-b", "c-e1-b"}; !reflect.DeepEqual(processor.entered, want) {
+			t.Errorf("OnEnter = %v, want %v", processor.entered, want)
+		}
+	})
+	t.Run("Transition Processor Precedence", func(t *testing.T) {
+		machine, v1, v2 := &enterFromProcessor{}, &enterProcessor{}, &enterFromProcessor{}
+		sm := newMachine().
+			Transitions(
+				gofsm.Transition{From: "a", Event: "e1", To: []gofsm.State{"b"}, Action: gofsm.NoopAction, Processor: v1},
+				gofsm.Transition{From: "a", Event: "e2", To: []gofsm.State{"c"}, Action: gofsm.NoopAction, Processor: v1, ProcessorV2: v2},
+			).
+			ProcessorV2(machine)
+		_, _ = sm.Trigger(context.TODO(), "a", "e1")
+		_, _ = sm.Trigger(context.TODO(), "a", "e2")
+		if !reflect.DeepEqual(v1.entered, []gofsm.State{"b"}) || !reflect.DeepEqual(v2.entered, []string{"a-e2-c"}) || machine.entered != nil {
+			t.Errorf("OnEnter v1 = %v, v2 = %v, machine = %v", v1.entered, v2.entered, machine.entered)
+		}
+	})
+	t.Run("Adapt Nil", func(t *testing.T) {
+		if gofsm.AdaptProcessor(nil) != nil {
+			t.Errorf("AdaptProcessor(nil) want nil")
+		}
+	})
+}
+
+type recoveryProcessor struct {
+	recovery gofsm.State
+	entered  []string
+}
+
+func (p *recoveryProcessor) OnExit(ctx context.Context, state gofsm.State, event gofsm.Event) error {
+	return nil
+}
+
+func (p *recoveryProcessor) OnActionFailure(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State, err error) (gofsm.State, error) {
+	return p.recovery, err
+}
+
+func (p *recoveryProcessor) OnEnter(ctx context.Context, from gofsm.State, event gofsm.Event, to gofsm.State) error {
+	p.entered = append(p.entered, fmt.Sprintf("%s-%s-%s", from, event, to))
+	return nil
+}
+
+func TestStateMachine_ProcessorV3(t *testing.T) {
+	failure := func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (gofsm.State, error) {
+		return "", errors.New("action error")
+	}
+	tests := []struct {
+		name       string
+		recovery   gofsm.State
+		errorState gofsm.State
+		want       gofsm.State
+		entered    []string
+	}{
+		{"Recovery State", "retry", gofsm.None, "retry", []string{"a-e1-retry"}},
+		{"Recovery Before Error State", "retry", "error", "retry", []string{"a-e1-retry"}},
+		{"Error State", gofsm.None, "error", "error", []string{"a-e1-error"}},
+		{"Stay", gofsm.None, gofsm.None, "a", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := &recoveryProcessor{recovery: tt.recovery}
+			i := gofsm.New("").
+				States(gofsm.StatesDef{"a": "", "b": "", "retry": "", "error": ""}).
+				Events(gofsm.EventsDef{"e1": ""}).
+				ErrorState(tt.errorState).
+				Transitions(gofsm.Transition{From: "a", Event: "e1", To: []gofsm.State{"b"}, Action: failure}).
+				ProcessorV3(processor).
+				NewInstance("a")
+			got, err := i.Fire(context.TODO(), "e1")
+			if err == nil || got != tt.want || i.Current() != tt.want {
+				t.Errorf("Instance.Fire() = %v, %v, current %v, want %v", got, err, i.Current(), tt.want)
+			}
+			if !reflect.DeepEqual(processor.entered, tt.entered) {
+				t.Errorf("OnEnter = %v, want %v", processor.entered, tt.entered)
+			}
+		})
+	}
+	t.Run("Adapt Nil", func(t *testing.T) {
+		if gofsm.AdaptProcessorV2(nil) != nil {
+			t.Errorf("AdaptProcessorV2(nil) want nil")
+		}
+	})
+}
+
+func TestStateMachine_TriggerWithProcessor(t *testing.T) {
+	machine, transition, override := &enterFromProcessor{}, &enterProcessor{}, &enterProcessor{}
+	sm := gofsm.New("").
+		States(gofsm.StatesDef{"a": "", "b": "", "c": ""}).
+		Events(gofsm.EventsDef{"e1": "", "e2": ""}).
+		Transitions(
+			gofsm.Transition{From: "a", Event: "e1", To: []gofsm.State{"b"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "a", Event: "e2", To: []gofsm.State{"c"}, Action: gofsm.NoopAction, Processor: transition},
+		).
+		ProcessorV2(machine)
+
+	tests := []struct {
+		name      string
+		event     gofsm.Event
+		processor gofsm.EventProcessor
+		want      gofsm.State
+	}{
+		{"Override Machine", "e1", override, "b"},
+		{"Override Transition", "e2", override, "c"},
+		{"Nil Fallback", "e1", nil, "b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sm.TriggerWithProcessor(context.TODO(), "a", tt.event, tt.processor)
+			if err != nil || got != tt.want {
+				t.Errorf("StateMachine.TriggerWithProcessor() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+	if want := []gofsm.State{"b", "c"}; !reflect.DeepEqual(override.entered, want) {
+		t.Errorf("override OnEnter = %v, want %v", override.entered, want)
+	}
+	if transition.entered != nil || !reflect.DeepEqual(machine.entered, []string{"a-e1-b"}) {
+		t.Errorf("OnEnter transition = %v, machine = %v", transition.entered, machine.entered)
+	}
+}
+
+func TestStateMachine_TriggerX(t *testing.T) {
+	pass := func(ctx context.Context, from gofsm.State, event gofsm.Event) (bool, error) {
+		return true, nil
+	}
+	sm := gofsm.New("").
+		States(gofsm.StatesDef{"a": "", "b": "", "c": ""}).
+		Events(gofsm.EventsDef{"e1": "", "e2": "", "e3": ""}).
+		Transitions(
+			gofsm.Transition{From: "a", Event: "e1", To: []gofsm.State{"b", "c"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "a", Event: "e2", To: []gofsm.State{"c"}, Action: gofsm.NoopAction, Guard: pass, Priority: 1},
+		)
+
+	tests := []struct {
+		name      string
+		event     gofsm.Event
+		want      gofsm.State
+		wantTo    []gofsm.State
+		wantGuard bool
+		wantErr   bool
+	}{
+		{"NFA", "e1", "b", []gofsm.State{"b", "c"}, false, false},
+		{"Guarded", "e2", "c", []gofsm.State{"c"}, true, false},
+		{"No Transition", "e3", "", nil, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sm.TriggerX(context.TODO(), "a", tt.event)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("StateMachine.TriggerX() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.State != tt.want || got.GuardEvaluated != tt.wantGuard || !reflect.DeepEqual(got.Transition.To, tt.wantTo) {
+				t.Errorf("StateMachine.TriggerX() = %+v, want %v %v %v", got, tt.want, tt.wantTo, tt.wantGuard)
+			}
+			if !tt.wantErr && (got.Transition.From != "a" || got.Transition.Event != tt.event) {
+				t.Errorf("StateMachine.TriggerX() transition = %v", got.Transition)
+			}
+		})
+	}
+
+	// 返回的是副本，修改不影响状态机
+	got, _ := sm.TriggerX(context.TODO(), "a", "e1")
+	got.Transition.To[0] = "c"
+	if got, _ := sm.Trigger(context.TODO(), "a", "e1"); got != "b" {
+		t.Errorf("StateMachine.Trigger() = %v, want b", got)
+	}
+}
+
+func TestStateMachine_OnNone(t *testing.T) {
+	none := func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (gofsm.State, error) {
+		return gofsm.None, nil
+	}
+	tests := []struct {
+		name      string
+		policy    gofsm.NonePolicy
+		event     gofsm.Event
+		want      gofsm.State
+		wantEnter []gofsm.State
+		wantErr   error
+	}{
+		{"DFA Stay", gofsm.NoneStay, "dfa", "a", nil, nil},
+		{"NFA Stay", gofsm.NoneStay, "nfa", "a", nil, nil},
+		{"DFA Error", gofsm.NoneError, "dfa", "", nil, gofsm.ErrNoneTarget},
+		{"NFA Error", gofsm.NoneError, "nfa", "", nil, gofsm.ErrNoneTarget},
+		{"No Target", gofsm.NoneError, "none", "", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := &enterProcessor{}
+			sm := gofsm.New("").
+				States(gofsm.StatesDef{"a": "", "b": "", "c": ""}).
+				Events(gofsm.EventsDef{"dfa": "", "nfa": "", "none": ""}).
+				Transitions(
+					gofsm.Transition{From: "a", Event: "dfa", To: []gofsm.State{"b"}, Action: none},
+					gofsm.Transition{From: "a", Event: "nfa", To: []gofsm.State{"b", "c"}, Action: none},
+					gofsm.Transition{From: "a", Event: "none", Action: none},
+				).
+				Processor(processor).
+				OnNone(tt.policy)
+			got, err := sm.Trigger(context.TODO(), "a", tt.event)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("StateMachine.Trigger() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("StateMachine.Trigger() = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(processor.entered, tt.wantEnter) {
+				t.Errorf("OnEnter = %v, want %v", processor.entered, tt.wantEnter)
+			}
+		})
+	}
+}
+
+func TestStateMachine_String(t *testing.T) {
+	tests := []struct {
+		name string
+		sm   *gofsm.StateMachine
+		want string
+	}{
+		{"Empty", gofsm.New(""), "StateMachine[] 0 states"},
+		{"Order", newOrderMachine().Transitions(
+			gofsm.Transition{From: "new", Event: "pay", To: []gofsm.State{"imported"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "new", Event: "cancel", To: []gofsm.State{"sent"}, Action: gofsm.NoopAction},
+		), "StateMachine[order] 4 states\n" +
+			"new --(cancel)--> sent\n" +
+			"new --(pay)--> imported\n" +
+			"new --(pay)--> paid\n" +
+			"paid --(send)--> sent"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fmt.Sprint(tt.sm); got != tt.want {
+				t.Errorf("StateMachine.String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStateMachine_FreezeTerminal(t *testing.T) {
+	tests := []struct {
+		name    string
+		freeze  bool
+		from    gofsm.State
+		event   gofsm.Event
+		want    gofsm.State
+		wantErr error
+	}{
+		{"Not Frozen", false, "sent", "pay", "new", nil},
+		{"Frozen", true, "sent", "pay", "", gofsm.ErrTerminalState},
+		{"Frozen Not Terminal", true, "paid", "send", "sent", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newOrderMachine().
+				Transitions(gofsm.Transition{From: "sent", Event: "pay", To: []gofsm.State{"new"}, Action: gofsm.NoopAction}).
+				FreezeTerminal(tt.freeze)
+			got, err := sm.Trigger(context.TODO(), tt.from, tt.event)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("StateMachine.Trigger() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("StateMachine.Trigger() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShowAll(t *testing.T) {
+	other := newOrderMachine().Name("refund")
+	got := gofsm.ShowAll(newOrderMachine(), other)
+	wants := []string{
+		`<b>[order]</b> State Graph" as graph1 {`,
+		`<b>[refund]</b> State Graph" as graph2 {`,
+		`state "paid" as g1_paid`,
+		`state "paid" as g2_paid`,
+		"[*] --> g2_new",
+		"g2_new --> g2_paid : (pay)",
+		"g1_sent --> [*]",
+	}
+	for _, want := range wants {
+		if !strings.Contains(got, want) {
+			t.Errorf("ShowAll() = %v, want contains %q", got, want)
+		}
+	}
+	if n := strings.Count(got, "@startuml"); n != 1 {
+		t.Errorf("ShowAll() contains %d documents, want 1", n)
+	}
+}
+
+func TestStateMachine_ShowFiltered(t *testing.T) {
+	sm := gofsm.New("").
+		States(gofsm.StatesDef{"new": "", "paid": "", "sent": "", "closed": ""}).
+		Events(gofsm.EventsDef{"pay": "", "send": "", "close": ""}).
+		Start([]gofsm.State{"new"}).
+		Transitions(
+			gofsm.Transition{From: "new", Event: "pay", To: []gofsm.State{"paid"}, Action: gofsm.NoopAction, Tags: []string{"automated"}},
+			gofsm.Transition{From: "paid", Event: "send", To: []gofsm.State{"sent"}, Action: gofsm.NoopAction, Tags: []string{"automated"}},
+			gofsm.Transition{From: "paid", Event: "close", To: []gofsm.State{"closed"}, Action: gofsm.NoopAction, Tags: []string{"admin-only"}},
+		)
+
+	tests := []struct {
+		name    string
+		tag     string
+		want    []string
+		notWant []string
+	}{
+		{"Automated", "automated",
+			[]string{"[*] --> new", "new --> paid", "paid --> sent", `state "sent" as sent`},
+			[]string{"paid --> closed", `state "closed"`}},
+		{"Admin Only", "admin-only",
+			[]string{"paid --> closed", `state "closed" as closed`},
+			[]string{"[*] --> new", "new --> paid", `state "new"`}},
+		{"Unknown", "unknown", nil, []string{"-->", `state "`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sm.ShowFiltered(tt.tag)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("StateMachine.ShowFiltered() = %v, want contains %q", got, want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("StateMachine.ShowFiltered() = %v, want not contains %q", got, notWant)
+				}
+			}
+		})
+	}
+
+	t.Run("Merge Tags", func(t *testing.T) {
+		merged := gofsm.New("").
+			States(gofsm.StatesDef{"a": "", "b": "", "c": ""}).
+			Events(gofsm.EventsDef{"e": ""}).
+			Transitions(
+				gofsm.Transition{From: "a", Event: "e", To: []gofsm.State{"b"}, Action: gofsm.NoopAction, Tags: []string{"x"}},
+				gofsm.Transition{From: "a", Event: "e", To: []gofsm.State{"c"}, Action: gofsm.NoopAction, Tags: []string{"x", "y"}},
+			)
+		if got := merged.Spec().Transitions[0].Tags; !reflect.DeepEqual(got, []string{"x", "y"}) {
+			t.Errorf("Tags = %v, want [x y]", got)
+		}
+	})
+}
+
+func TestStateMachine_UnknownErrors(t *testing.T) {
+	var unknownStates []gofsm.State
+	var unknownEvents []gofsm.Event
+	reject := func(ctx context.Context, from gofsm.State, event gofsm.Event) (bool, error) {
+		return false, nil
+	}
+	sm := newOrderMachine().
+		Transitions(gofsm.Transition{From: "sent", Event: "pay", To: []gofsm.State{"paid"}, Action: gofsm.NoopAction, Guard: reject}).
+		OnUnknownState(func(state gofsm.State) { unknownStates = append(unknownStates, state) }).
+		OnUnknownEvent(func(event gofsm.Event) { unknownEvents = append(unknownEvents, event) })
+
+	tests := []struct {
+		name    string
+		from    gofsm.State
+		event   gofsm.Event
+		want    error
+		wantMsg string
+	}{
+		{"Unknown State", "lost", "pay", gofsm.ErrUnknownState, "状态机不包含状态lost"},
+		{"Unknown Event", "new", "refund", gofsm.ErrUnknownEvent, "状态机不包含事件 refund"},
+		{"No Transition", "new", "send", gofsm.ErrNoTransition, "没有定义状态转换事件 [new --send--> ???]"},
+		{"Guard Rejected", "sent", "pay", gofsm.ErrGuardRejected, "状态转换条件不满足 [sent --pay--> ???]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := sm.Trigger(context.TODO(), tt.from, tt.event)
+			if !errors.Is(err, tt.want) || err.Error() != tt.wantMsg {
+				t.Errorf("StateMachine.Trigger() error = %v, want %v", err, tt.wantMsg)
+			}
+		})
+	}
+	if !reflect.DeepEqual(unknownStates, []gofsm.State{"lost"}) || !reflect.DeepEqual(unknownEvents, []gofsm.Event{"refund"}) {
+		t.Errorf("unknown states = %v, events = %v", unknownStates, unknownEvents)
+	}
+}
+
+func TestStateMachine_StrictMembership(t *testing.T) {
+	sm := gofsm.New("draft").StrictMembership(false).
+		Transitions(gofsm.Transition{From: "new", Event: "pay", To: []gofsm.State{"paid"}, Action: gofsm.NoopAction})
+
+	tests := []struct {
+		name  string
+		from  gofsm.State
+		event gofsm.Event
+		want  gofsm.State
+		err   error
+	}{
+		{"Undeclared Transition", "new", "pay", "paid", nil},
+		{"Unknown State", "lost", "pay", "", gofsm.ErrNoTransition},
+		{"Unknown Event", "new", "refund", "", gofsm.ErrNoTransition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sm.Trigger(context.TODO(), tt.from, tt.event)
+			if !errors.Is(err, tt.err) || (tt.err == nil && got != tt.want) {
+				t.Errorf("StateMachine.Trigger() = %v, %v, want %v, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+
+	if _, err := newOrderMachine().Trigger(context.TODO(), "lost", "pay"); !errors.Is(err, gofsm.ErrUnknownState) {
+		t.Errorf("strict StateMachine.Trigger() error = %v, want %v", err, gofsm.ErrUnknownState)
+	}
+
+	pseudo := gofsm.New("").
+		States(gofsm.StatesDef{"new": "", "sent": ""}).
+		Events(gofsm.EventsDef{"create": "", "archive": ""}).
+		Transitions(
+			gofsm.Transition{From: gofsm.Start, Event: "create", To: []gofsm.State{"new"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "sent", Event: "archive", To: []gofsm.State{gofsm.End}, Action: gofsm.NoopAction},
+		)
+	if got, err := pseudo.Trigger(context.TODO(), gofsm.Start, "create"); err != nil || got != "new" {
+		t.Errorf("strict StateMachine.Trigger(Start) = %v, %v, want new", got, err)
+	}
+	if got, err := pseudo.Trigger(context.TODO(), "sent", "archive"); err != nil || got != gofsm.End {
+		t.Errorf("strict StateMachine.Trigger(sent) = %v, %v, want %v", got, err, gofsm.End)
+	}
+}
+
+func TestStateMachine_ReservedState(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(sm *gofsm.StateMachine)
+		panic bool
+	}{
+		{"States PseudoState", func(sm *gofsm.StateMachine) { sm.States(gofsm.StatesDef{"a": "", "[*]": ""}) }, true},
+		{"States End", func(sm *gofsm.StateMachine) { sm.States(gofsm.StatesDef{gofsm.End: ""}) }, true},
+		{"From End", func(sm *gofsm.StateMachine) {
+			sm.Transitions(gofsm.Transition{From: gofsm.End, Event: "e", To: []gofsm.State{"a"}, Action: gofsm.NoopAction})
+		}, true},
+		{"From PseudoState", func(sm *gofsm.StateMachine) {
+			sm.Transitions(gofsm.Transition{From: "[*]", Event: "e", To: []gofsm.State{"a"}, Action: gofsm.NoopAction})
+		}, true},
+		{"To Start", func(sm *gofsm.StateMachine) {
+			sm.Transitions(gofsm.Transition{From: "a", Event: "e", To: []gofsm.State{"b", gofsm.Start}, Action: gofsm.NoopAction})
+		}, true},
+		{"To PseudoState", func(sm *gofsm.StateMachine) {
+			sm.Transitions(gofsm.Transition{From: "a", Event: "e", To: []gofsm.State{"[*]"}, Action: gofsm.NoopAction})
+		}, true},
+		{"From Start To End", func(sm *gofsm.StateMachine) {
+			sm.Transitions(gofsm.Transition{From: gofsm.Start, Event: "e", To: []gofsm.State{gofsm.End}, Action: gofsm.NoopAction})
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := gofsm.New("")
+			defer func() {
+				r := recover()
+				if err, _ := r.(error); (r != nil) != tt.panic || (r != nil && !errors.Is(err, gofsm.ErrReservedState)) {
+					t.Errorf("recover() = %v, want panic %v", r, tt.panic)
+				}
+				if tt.panic {
+					sm.EachTransition(func(from gofsm.State, event gofsm.Event, to gofsm.State) bool {
+						t.Errorf("StateMachine.Transitions() added %v --%v--> %v before panic", from, event, to)
+						return true
+					})
+				}
+			}()
+			tt.build(sm)
+		})
+	}
+}
+
+func TestStateMachine_SortTargets(t *testing.T) {
+	tests := []struct {
+		name        string
+		sortTargets bool
+		transitions []gofsm.Transition
+		want        []gofsm.State
+		weights     []float64
+	}{
+		{"Merge", false, []gofsm.Transition{
+			{From: "a", Event: "e", To: []gofsm.State{"c", "", "c"}, Action: gofsm.NoopAction},
+			{From: "a", Event: "e", To: []gofsm.State{"", "b", "c"}, Action: gofsm.NoopAction},
+		}, []gofsm.State{"c", "b"}, nil},
+		{"Merge Sorted", true, []gofsm.Transition{
+			{From: "a", Event: "e", To: []gofsm.State{"c", "", "c"}, Action: gofsm.NoopAction},
+			{From: "a", Event: "e", To: []gofsm.State{"", "b", "c"}, Action: gofsm.NoopAction},
+		}, []gofsm.State{"b", "c"}, nil},
+		{"Weighted Sorted", true, []gofsm.Transition{
+			{From: "a", Event: "e", To: []gofsm.State{"c", "", "b"}, Weights: []float64{3, 2, 1}, Action: gofsm.NoopAction},
+		}, []gofsm.State{"b", "c"}, []float64{1, 3}},
+		{"Merge Then Weighted", false, []gofsm.Transition{
+			{From: "a", Event: "e", To: []gofsm.State{"c"}, Action: gofsm.NoopAction},
+			{From: "a", Event: "e", To: []gofsm.State{"c", "b"}, Action: gofsm.NoopAction},
+			{From: "a", Event: "e", To: []gofsm.State{"b"}, Weights: []float64{2}, Action: gofsm.NoopAction},
+		}, []gofsm.State{"c", "b"}, []float64{1, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := gofsm.New("").
+				States(gofsm.StatesDef{"a": "", "b": "", "c": ""}).
+				Events(gofsm.EventsDef{"e": ""}).
+				SortTargets(tt.sortTargets).
+				Transitions(tt.transitions...).
+				Seal()
+			got := sm.Spec().Transitions[0]
+			if !reflect.DeepEqual(got.To, tt.want) || !reflect.DeepEqual(got.Weights, tt.weights) {
+				t.Errorf("To = %v, Weights = %v, want %v, %v", got.To, got.Weights, tt.want, tt.weights)
+			}
+			if got := plantUMLScript(t, sm); !strings.Contains(got, "a --> c") || strings.Contains(got, "a -->  ") {
+				t.Errorf("PlantUML script = %v", got)
+			}
+		})
+	}
+}
+
+func TestStateMachine_Show_TransitionDesc(t *testing.T) {
+	sm := gofsm.New("").
+		States(gofsm.StatesDef{"review": "", "escalated": "", "approved": ""}).
+		Events(gofsm.EventsDef{"approve": "审批通过"}).
+		Transitions(
+			gofsm.Transition{From: "review", Event: "approve", To: []gofsm.State{"approved"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "escalated", Event: "approve", To: []gofsm.State{"approved"}, Action: gofsm.NoopAction, Desc: "主管审批"},
+			gofsm.Transition{From: "escalated", Event: "approve", To: []gofsm.State{"review"}, Action: gofsm.NoopAction, Desc: "合并时忽略"},
+		)
+
+	got := plantUMLScript(t, sm)
+	for _, want := range []string{
+		"review --> approved : (approve) 审批通过",
+		"escalated --> approved : <font color=red><b>(approve) 主管审批</b></font>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("PlantUML script = %v, want contains %q", got, want)
+		}
+	}
+	if strings.Contains(got, "合并时忽略") {
+		t.Errorf("PlantUML script = %v, want first desc kept on merge", got)
+	}
+}
+
+func TestStateMachine_Show_StartEnd(t *testing.T) {
+	sm := gofsm.New("").
+		States(gofsm.StatesDef{"a": "", "b": ""}).
+		Events(gofsm.EventsDef{"go": ""}).
+		Transitions(
+			gofsm.Transition{From: gofsm.Start, Event: "go", To: []gofsm.State{"a"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "a", Event: "go", To: []gofsm.State{"b", gofsm.End}, Action: gofsm.NoopAction},
+		)
+	if gofsm.Start == gofsm.End {
+		t.Fatalf("Start and End want distinct sentinels")
+	}
+	got := plantUMLScript(t, sm)
+	for _, want := range []string{"[*] --> a : (go)", "a --> [*] :"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("PlantUML script = %v, want contains %q", got, want)
+		}
+	}
+	for _, notWant := range []string{gofsm.Start, gofsm.End} {
+		if strings.Contains(got, notWant) {
+			t.Errorf("PlantUML script = %v, want not contains %q", got, notWant)
+		}
+	}
+}
+
+func TestStateMachine_TriggerOrStay(t *testing.T) {
+	guard := func(pass bool, err error) gofsm.Guard {
+		return func(ctx context.Context, from gofsm.State, event gofsm.Event) (bool, error) {
+			return pass, err
+		}
+	}
+	failure := func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (gofsm.State, error) {
+		return gofsm.None, errors.New("action error")
+	}
+	sm := newOrderMachine().
+		Events(gofsm.EventsDef{"pay": "", "send": "", "refund": "", "check": "", "fail": ""}).
+		FreezeTerminal(true).
+		Transitions(
+			gofsm.Transition{From: "paid", Event: "refund", To: []gofsm.State{"new"}, Action: gofsm.NoopAction, Guard: guard(false, nil)},
+			gofsm.Transition{From: "paid", Event: "check", To: []gofsm.State{"new"}, Action: gofsm.NoopAction, Guard: guard(true, errors.New("guard error"))},
+			gofsm.Transition{From: "paid", Event: "fail", To: []gofsm.State{"new"}, Action: failure},
+		)
+
+	tests := []struct {
+		name    string
+		from    gofsm.State
+		event   gofsm.Event
+		want    gofsm.State
+		moved   bool
+		wantErr bool
+	}{
+		{"Transition", "new", "pay", "paid", true, false},
+		{"No Transition", "new", "send", "new", false, false},
+		{"Guard Rejected", "paid", "refund", "paid", false, false},
+		{"Terminal", "sent", "pay", "sent", false, false},
+		{"Guard Error", "paid", "check", "", false, true},
+		{"Action Error", "paid", "fail", "", false, true},
+		{"Unknown State", "lost", "pay", "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, moved, err := sm.TriggerOrStay(context.TODO(), tt.from, tt.event)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("StateMachine.TriggerOrStay() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want || moved != tt.moved {
+				t.Errorf("StateMachine.TriggerOrStay() = %v, %v, want %v, %v", got, moved, tt.want, tt.moved)
+			}
+		})
 	}
 }
@@ -0,0 +1,7 @@
+/**
+状态机拓扑定义的 protobuf 消息，由 protoc-gen-go 根据 fsm.proto 生成，可以直接用于 gRPC
+修改 fsm.proto 后执行 go generate 重新生成
+*/
+package fsmpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative fsm.proto
@@ -0,0 +1,77 @@
+package fsmpb
+
+import (
+	"bytes"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+var _ proto.Message = (*StateMachine)(nil)
+
+func TestStateMachine_Marshal(t *testing.T) {
+	m := &StateMachine{
+		Name:        "n",
+		States:      map[string]string{"b": "", "a": "x"},
+		Transitions: []*Transition{{From: "a", Event: "e", To: []string{"b"}, Priority: 1, Guarded: true}},
+	}
+	want := []byte{
+		0x0a, 0x01, 'n',
+		0x12, 0x06, 0x0a, 0x01, 'a', 0x12, 0x01, 'x',
+		0x12, 0x05, 0x0a, 0x01, 'b', 0x12, 0x00,
+		0x42, 0x0d, 0x0a, 0x01, 'a', 0x12, 0x01, 'e', 0x1a, 0x01, 'b', 0x20, 0x01, 0x30, 0x01,
+	}
+	got, err := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	if err != nil || !bytes.Equal(got, want) {
+		t.Errorf("Marshal() = %x, %v, want %x", got, err, want)
+	}
+}
+
+func TestStateMachine_Unmarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		m    *StateMachine
+	}{
+		{"Empty", &StateMachine{}},
+		{"Full", &StateMachine{
+			Name:       "order",
+			States:     map[string]string{"new": "新建", "paid": ""},
+			Events:     map[string]string{"pay": "支付"},
+			Aliases:    map[string]string{"charge": "pay"},
+			Start:      []string{"new"},
+			End:        []string{"paid"},
+			ErrorState: "new",
+			Transitions: []*Transition{
+				{From: "new", Event: "pay", To: []string{"paid", "new"}, Priority: -3, Weights: []float64{0.5, 1.5}, Tags: []string{"a", "b"}, Desc: "d"},
+			},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := proto.Marshal(tt.m)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			got := &StateMachine{}
+			if err := proto.Unmarshal(data, got); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !proto.Equal(got, tt.m) {
+				t.Errorf("Unmarshal() = %v, want %v", got, tt.m)
+			}
+		})
+	}
+}
+
+func TestStateMachine_Unmarshal_Malformed(t *testing.T) {
+	for _, data := range [][]byte{{0x0a, 0x05, 'a'}, {0x80}, {0x0b}} {
+		if err := proto.Unmarshal(data, &StateMachine{}); err == nil {
+			t.Errorf("Unmarshal(%x) want error", data)
+		}
+	}
+	// 未知字段被保留，不影响已知字段
+	m := &StateMachine{}
+	if err := proto.Unmarshal([]byte{0x78, 0x01, 0x0a, 0x01, 'n'}, m); err != nil || m.GetName() != "n" {
+		t.Errorf("Unmarshal() unknown field = %v, %v", m, err)
+	}
+}
@@ -1,7 +1,10 @@
 module github.com/threeq/gofsm
 
+go 1.18
+
 require (
 	github.com/threeq/goassert v0.0.1
 	github.com/threeq/gofaker v0.0.1
+	google.golang.org/protobuf v1.33.0
 	qiniupkg.com/x v7.0.8+incompatible
 )
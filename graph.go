@@ -0,0 +1,389 @@
+package gofsm
+
+import (
+	"context"
+	"sort"
+)
+
+/**
+遍历所有状态转换边，NFA 的多个目标状态展开为多条边
+按 from、event 排序，fn 返回 false 时停止遍历
+*/
+func (sm *StateMachine) EachTransition(fn func(from State, event Event, to State) bool) {
+	sm.sg.each(func(transfer *Transition) bool {
+		for _, to := range transfer.To {
+			if !fn(transfer.From, transfer.Event, to) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+/**
+按 from、event 排序遍历所有转换定义，相同 from、event 的转换按优先级顺序
+*/
+func (sg *stateGraph) each(fn func(transfer *Transition) bool) {
+	var froms []string
+	for from := range sg.transitions {
+		froms = append(froms, string(from))
+	}
+	sort.Strings(froms)
+
+	for _, from := range froms {
+		events := sg.transitions[State(from)]
+		var names []string
+		for event := range events {
+			names = append(names, string(event))
+		}
+		sort.Strings(names)
+
+		for _, event := range names {
+			for _, transfer := range events[Event(event)] {
+				if !fn(transfer) {
+					return
+				}
+			}
+		}
+	}
+}
+
+/**
+状态图统计信息
+*/
+type GraphStats struct {
+	States            int `json:"states"`
+	Events            int `json:"events"`
+	Transitions       int `json:"transitions"`
+	TerminalStates    int `json:"terminalStates"`
+	NFATransitions    int `json:"nfaTransitions"`
+	UnreachableStates int `json:"unreachableStates"`
+	MaxOutDegree      int `json:"maxOutDegree"`
+}
+
+/**
+统计状态图
+	- Transitions: 展开后的转换边数量
+	- NFATransitions: 合并后有多个目标状态的状态、事件数量
+	- UnreachableStates: 从开始状态无法到达的状态数量，没有定义开始状态时为 0
+	- MaxOutDegree: 单个状态最多的出边数量
+*/
+func (sm *StateMachine) Stats() GraphStats {
+	sg := sm.sg
+	stats := GraphStats{
+		States:         len(sg.states),
+		Events:         len(sg.events),
+		TerminalStates: len(sg.end),
+	}
+
+	outDegree := map[State]int{}
+	sg.each(func(transfer *Transition) bool {
+		stats.Transitions += len(transfer.To)
+		outDegree[transfer.From] += len(transfer.To)
+		return true
+	})
+	for _, events := range sg.transitions {
+		for _, transfers := range events {
+			if distinctTargets(transfers) > 1 {
+				stats.NFATransitions++
+			}
+		}
+	}
+	for _, degree := range outDegree {
+		if degree > stats.MaxOutDegree {
+			stats.MaxOutDegree = degree
+		}
+	}
+
+	if reachable := sg.reachableFromRoots(); reachable != nil {
+		for state := range sg.states {
+			if !reachable[state] {
+				stats.UnreachableStates++
+			}
+		}
+	}
+	return stats
+}
+
+/**
+有转换的状态，按名称排序
+*/
+func (sg *stateGraph) sortedFroms() []State {
+	froms := make([]State, 0, len(sg.transitions))
+	for from := range sg.transitions {
+		froms = append(froms, from)
+	}
+	sort.Slice(froms, func(i, j int) bool { return froms[i] < froms[j] })
+	return froms
+}
+
+func sortedTransitionEvents(events map[Event][]*Transition) []Event {
+	names := make([]Event, 0, len(events))
+	for event := range events {
+		names = append(names, event)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+/**
+同一个状态同一个事件的所有转换合并后不同目标状态的数量，大于 1 时是 NFA 转换
+*/
+func distinctTargets(transfers []*Transition) int {
+	var targets []State
+	for _, transfer := range transfers {
+		targets = append(targets, transfer.To...)
+	}
+	return len(removeRepByMap(targets))
+}
+
+func sortedStates(states StatesDef) []State {
+	names := make([]State, 0, len(states))
+	for state := range states {
+		names = append(names, state)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+/**
+是否是确定性状态机（DFA）：同一个状态同一个事件的所有转换合并后最多只有一个目标状态，与图中显示的 DFA/NFA 一致
+*/
+func (sm *StateMachine) IsDeterministic() bool {
+	for _, events := range sm.sg.transitions {
+		for _, transfers := range events {
+			if distinctTargets(transfers) > 1 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+/**
+开始状态：Start 中定义的状态和从 Start 转换到的状态
+*/
+func (sg *stateGraph) roots() []State {
+	roots := append([]State(nil), sg.start...)
+	for _, transfers := range sg.transitions[Start] {
+		for _, transfer := range transfers {
+			roots = append(roots, transfer.To...)
+		}
+	}
+	return roots
+}
+
+/**
+从开始状态可以到达的所有状态，没有开始状态时返回 nil
+错误状态和死状态不通过转换进入，作为遍历的起点；After 定时转换的目标状态也可以到达
+*/
+func (sg *stateGraph) reachableFromRoots() map[State]bool {
+	roots := sg.roots()
+	if len(roots) == 0 {
+		return nil
+	}
+	for _, state := range []State{sg.errorState, sg.deadState} {
+		if state != None {
+			roots = append(roots, state)
+		}
+	}
+	reachable := sg.reachable(roots...)
+	for grown := true; grown; {
+		grown = false
+		for from, t := range sg.timeouts {
+			for _, to := range t.transfer.To {
+				if reachable[from] && !reachable[to] && to != End && to != None {
+					roots, grown = append(roots, to), true
+				}
+			}
+		}
+		if grown {
+			reachable = sg.reachable(roots...)
+		}
+	}
+	return reachable
+}
+
+/**
+从 from 出发可以到达的所有状态（包含 from 本身），不包含 End 和 None
+与 States 比较即可得到不可达的状态，返回值可以修改
+*/
+func (sm *StateMachine) Reachable(from State) map[State]bool {
+	return sm.sg.reachable(from)
+}
+
+/**
+广度优先遍历 from 可以到达的所有状态（包含 from 本身）
+*/
+func (sg *stateGraph) reachable(from ...State) map[State]bool {
+	visited := map[State]bool{}
+	queue := append([]State(nil), from...)
+	for _, state := range from {
+		visited[state] = true
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		for _, transfers := range sg.transitions[state] {
+			for _, transfer := range transfers {
+				for _, to := range transfer.To {
+					if to == None || to == End || visited[to] {
+						continue
+					}
+					visited[to] = true
+					queue = append(queue, to)
+				}
+			}
+		}
+	}
+	return visited
+}
+
+/**
+从 from 到 to 事件最少的路径，返回依次触发的事件和是否可以到达，from 与 to 相同时返回空路径
+广度优先遍历，NFA 的多个目标状态展开为多条边，ε 转换作为 None 事件，不执行 Guard
+路径长度相同时按 from、event 排序选择第一条，结果是确定的
+*/
+func (sm *StateMachine) ShortestPath(from, to State) ([]Event, bool) {
+	from, to = sm.normState(from), sm.normState(to)
+	sg := sm.sg
+	type step struct {
+		from  State
+		event Event
+	}
+	prev := map[State]step{}
+	visited := map[State]bool{from: true}
+	queue := []State{from}
+	for len(queue) > 0 && !visited[to] {
+		state := queue[0]
+		queue = queue[1:]
+		events := sg.transitions[state]
+		for _, event := range sortedTransitionEvents(events) {
+			for _, transfer := range events[event] {
+				for _, target := range transfer.To {
+					if target == None || visited[target] {
+						continue
+					}
+					visited[target] = true
+					prev[target] = step{state, event}
+					queue = append(queue, target)
+				}
+			}
+		}
+	}
+	if !visited[to] {
+		return nil, false
+	}
+
+	path := []Event{}
+	for state := to; state != from; state = prev[state].from {
+		path = append(path, prev[state].event)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, true
+}
+
+/**
+状态上可以触发的事件，按名称排序
+设置 FollowEpsilon 时包含 ε 闭包中所有状态上的事件，不包含 None
+状态机封存后直接返回索引中的结果，返回值不能修改
+*/
+func (sm *StateMachine) AvailableEvents(state State) []Event {
+	state = sm.normState(state)
+	if sm.sg.eventIndex != nil {
+		return sm.sg.eventIndex[state]
+	}
+	return sm.sg.scanEvents(state, sm.epsilon)
+}
+
+/**
+状态上当前可以触发的事件：在 AvailableEvents 的基础上执行 Guard，只返回至少有一个转换的 Guard 通过的事件
+没有 Guard 的转换总是可以触发，Guard 返回错误或者转换被 Disable 时事件不可用
+*/
+func (sm *StateMachine) AvailableEventsCtx(ctx context.Context, state State) []Event {
+	state = sm.normState(state)
+	states := []State{state}
+	if sm.epsilon {
+		states = sm.sg.epsilonClose(state)
+	}
+	var events []Event
+	for _, event := range sm.AvailableEvents(state) {
+		for _, s := range states {
+			if _, _, err := sm.match(ctx, s, event, nil); err == nil {
+				events = append(events, event)
+				break
+			}
+		}
+	}
+	return events
+}
+
+func (sg *stateGraph) scanEvents(state State, epsilon bool) []Event {
+	states := []State{state}
+	if epsilon {
+		states = sg.epsilonClose(state)
+	}
+	seen := map[Event]bool{}
+	var events []Event
+	for _, s := range states {
+		for event, transfers := range sg.transitions[s] {
+			if len(transfers) == 0 || seen[event] || (epsilon && event == None) {
+				continue
+			}
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i] < events[j] })
+	return events
+}
+
+/**
+是否所有状态都可以到达结束状态，返回无法到达任何结束状态的状态（已排序）
+转换到 End 的状态也视为可以到达结束状态
+*/
+func (sm *StateMachine) AllStatesCanReachEnd() (bool, []State) {
+	sg := sm.sg
+	reverse := map[State][]State{}
+	reached := map[State]bool{}
+	var queue []State
+	for _, state := range sg.end {
+		if !reached[state] {
+			reached[state] = true
+			queue = append(queue, state)
+		}
+	}
+	sg.each(func(transfer *Transition) bool {
+		for _, to := range transfer.To {
+			reverse[to] = append(reverse[to], transfer.From)
+		}
+		return true
+	})
+	for _, from := range reverse[End] {
+		if !reached[from] {
+			reached[from] = true
+			queue = append(queue, from)
+		}
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		for _, from := range reverse[state] {
+			if !reached[from] {
+				reached[from] = true
+				queue = append(queue, from)
+			}
+		}
+	}
+
+	var stuck []State
+	for state := range sg.states {
+		if !reached[state] {
+			stuck = append(stuck, state)
+		}
+	}
+	sort.Slice(stuck, func(i, j int) bool { return stuck[i] < stuck[j] })
+	return len(stuck) == 0, stuck
+}
@@ -0,0 +1,237 @@
+package gofsm_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestStateMachine_EachTransition(t *testing.T) {
+	sm := gofsm.New("").Transitions(
+		gofsm.Transition{From: "b", Event: "e1", To: []gofsm.State{"c"}, Action: gofsm.NoopAction},
+		gofsm.Transition{From: "a", Event: "e2", To: []gofsm.State{"c", "b"}, Action: gofsm.NoopAction},
+		gofsm.Transition{From: "a", Event: "e1", To: []gofsm.State{"b"}, Action: gofsm.NoopAction},
+	)
+
+	tests := []struct {
+		name  string
+		limit int
+		want  []string
+	}{
+		{"All", -1, []string{"a-e1-b", "a-e2-c", "a-e2-b", "b-e1-c"}},
+		{"Stop Early", 2, []string{"a-e1-b", "a-e2-c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			sm.EachTransition(func(from gofsm.State, event gofsm.Event, to gofsm.State) bool {
+				got = append(got, string(from)+"-"+string(event)+"-"+string(to))
+				return len(got) != tt.limit
+			})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StateMachine.EachTransition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStateMachine_Stats(t *testing.T) {
+	tests := []struct {
+		name string
+		sm   *gofsm.StateMachine
+		want gofsm.GraphStats
+	}{
+		{"Empty", gofsm.New(""), gofsm.GraphStats{}},
+		{"Order", newOrderMachine(), gofsm.GraphStats{
+			States: 4, Events: 2, Transitions: 2, TerminalStates: 1, UnreachableStates: 1, MaxOutDegree: 1}},
+		{"Order Error State", newOrderMachine().ErrorState("imported"), gofsm.GraphStats{
+			States: 4, Events: 2, Transitions: 2, TerminalStates: 1, MaxOutDegree: 1}},
+		{"Order After", newOrderMachine().After("paid", time.Hour, "imported", nil), gofsm.GraphStats{
+			States: 4, Events: 2, Transitions: 2, TerminalStates: 1, MaxOutDegree: 1}},
+		{"NFA", gofsm.New("").
+			States(gofsm.StatesDef{"a": "", "b": "", "c": "", "d": ""}).
+			Events(gofsm.EventsDef{"e1": "", "e2": ""}).
+			Transitions(
+				gofsm.Transition{From: gofsm.Start, Event: gofsm.None, To: []gofsm.State{"a"}, Action: gofsm.NoopAction},
+				gofsm.Transition{From: "a", Event: "e1", To: []gofsm.State{"b", "c"}, Action: gofsm.NoopAction},
+				gofsm.Transition{From: "a", Event: "e2", To: []gofsm.State{"c"}, Action: gofsm.NoopAction},
+				gofsm.Transition{From: "c", Event: "e1", To: []gofsm.State{gofsm.End}, Action: gofsm.NoopAction},
+			), gofsm.GraphStats{
+			States: 4, Events: 2, Transitions: 5, NFATransitions: 1, UnreachableStates: 1, MaxOutDegree: 3}},
+		{"Separate Transitions", gofsm.New("").SeparateTransitions(true).Transitions(
+			gofsm.Transition{From: "a", Event: "e", To: []gofsm.State{"b"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "a", Event: "e", To: []gofsm.State{"c"}, Action: gofsm.NoopAction},
+		), gofsm.GraphStats{Transitions: 2, NFATransitions: 1, MaxOutDegree: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sm.Stats(); got != tt.want {
+				t.Errorf("StateMachine.Stats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStateMachine_IsDeterministic(t *testing.T) {
+	tests := []struct {
+		name string
+		sm   *gofsm.StateMachine
+		want bool
+	}{
+		{"Empty", gofsm.New(""), true},
+		{"DFA", newOrderMachine(), true},
+		{"NFA", newOrderMachine().Transitions(
+			gofsm.Transition{From: "new", Event: "pay", To: []gofsm.State{"sent"}, Action: gofsm.NoopAction},
+		), false},
+		{"Priority Alternatives", newOrderMachine().Transitions(
+			gofsm.Transition{From: "new", Event: "pay", To: []gofsm.State{"sent"}, Action: gofsm.NoopAction, Priority: 1},
+		), false},
+		{"Separate Transitions", gofsm.New("").SeparateTransitions(true).Transitions(
+			gofsm.Transition{From: "a", Event: "e", To: []gofsm.State{"b"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "a", Event: "e", To: []gofsm.State{"c"}, Action: gofsm.NoopAction},
+		), false},
+		{"Separate Same Target", gofsm.New("").SeparateTransitions(true).Transitions(
+			gofsm.Transition{From: "a", Event: "e", To: []gofsm.State{"b"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "a", Event: "e", To: []gofsm.State{"b"}, Action: gofsm.NoopAction},
+		), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sm.IsDeterministic(); got != tt.want {
+				t.Errorf("StateMachine.IsDeterministic() = %v, want %v", got, tt.want)
+			}
+			label := "<<DFA>>"
+			if !tt.want {
+				label = "<<NFA>>"
+			}
+			if script := plantUMLScript(t, tt.sm); !strings.Contains(script, "<b>"+label+"</b>") {
+				t.Errorf("StateMachine.WriteFormat() = %v, want contains %v", script, label)
+			}
+		})
+	}
+}
+
+func TestStateMachine_AllStatesCanReachEnd(t *testing.T) {
+	tests := []struct {
+		name  string
+		sm    *gofsm.StateMachine
+		want  bool
+		stuck []gofsm.State
+	}{
+		{"Imported Stuck", newOrderMachine(), false, []gofsm.State{"imported"}},
+		{"All Reach", newOrderMachine().Transitions(
+			gofsm.Transition{From: "imported", Event: "pay", To: []gofsm.State{"paid"}, Action: gofsm.NoopAction},
+		), true, nil},
+		{"Transition To End", newOrderMachine().End(nil).Transitions(
+			gofsm.Transition{From: "sent", Event: "send", To: []gofsm.State{gofsm.End}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "imported", Event: "send", To: []gofsm.State{gofsm.End}, Action: gofsm.NoopAction},
+		), true, nil},
+		{"No End", newOrderMachine().End(nil), false, []gofsm.State{"imported", "new", "paid", "sent"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, stuck := tt.sm.AllStatesCanReachEnd()
+			if got != tt.want || !reflect.DeepEqual(stuck, tt.stuck) {
+				t.Errorf("StateMachine.AllStatesCanReachEnd() = %v, %v, want %v, %v", got, stuck, tt.want, tt.stuck)
+			}
+		})
+	}
+}
+
+func TestStateMachine_Reachable(t *testing.T) {
+	sm := gofsm.New("").Transitions(
+		gofsm.Transition{From: "a", Event: "e1", To: []gofsm.State{"b", "c"}, Action: gofsm.NoopAction},
+		gofsm.Transition{From: "b", Event: "e1", To: []gofsm.State{"d"}, Action: gofsm.NoopAction},
+		gofsm.Transition{From: "d", Event: "e2", To: []gofsm.State{"a", gofsm.End}, Action: gofsm.NoopAction},
+		gofsm.Transition{From: "x", Event: "e1", To: []gofsm.State{"a"}, Action: gofsm.NoopAction},
+	)
+
+	tests := []struct {
+		name string
+		from gofsm.State
+		want map[gofsm.State]bool
+	}{
+		{"Cycle", "b", map[gofsm.State]bool{"a": true, "b": true, "c": true, "d": true}},
+		{"Sink", "c", map[gofsm.State]bool{"c": true}},
+		{"Whole Graph", "x", map[gofsm.State]bool{"a": true, "b": true, "c": true, "d": true, "x": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sm.Reachable(tt.from); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StateMachine.Reachable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStateMachine_ShortestPath(t *testing.T) {
+	sm := gofsm.New("").Transitions(
+		gofsm.Transition{From: "a", Event: "e1", To: []gofsm.State{"b", "c"}, Action: gofsm.NoopAction},
+		gofsm.Transition{From: "b", Event: "e1", To: []gofsm.State{"d"}, Action: gofsm.NoopAction},
+		gofsm.Transition{From: "c", Event: "e3", To: []gofsm.State{"d"}, Action: gofsm.NoopAction},
+		gofsm.Transition{From: "d", Event: "e2", To: []gofsm.State{"a", gofsm.End}, Action: gofsm.NoopAction},
+		gofsm.Transition{From: "a", Event: "e4", To: []gofsm.State{"x"}, Action: gofsm.NoopAction},
+		gofsm.Transition{From: "x", Event: "e1", To: []gofsm.State{"y"}, Action: gofsm.NoopAction},
+		gofsm.Transition{From: "y", Event: "e1", To: []gofsm.State{"z"}, Action: gofsm.NoopAction},
+		gofsm.Transition{From: "z", Event: "e1", To: []gofsm.State{"d"}, Action: gofsm.NoopAction},
+	)
+
+	tests := []struct {
+		name  string
+		from  gofsm.State
+		to    gofsm.State
+		want  []gofsm.Event
+		found bool
+	}{
+		{"Same State", "a", "a", []gofsm.Event{}, true},
+		{"NFA Target", "a", "c", []gofsm.Event{"e1"}, true},
+		{"Shortest", "a", "d", []gofsm.Event{"e1", "e1"}, true},
+		{"Cycle", "b", "c", []gofsm.Event{"e1", "e2", "e1"}, true},
+		{"End", "a", gofsm.End, []gofsm.Event{"e1", "e1", "e2"}, true},
+		{"Through Cycle", "c", "x", []gofsm.Event{"e3", "e2", "e4"}, true},
+		{"Not Found", "d", "lost", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := sm.ShortestPath(tt.from, tt.to)
+			if !reflect.DeepEqual(got, tt.want) || found != tt.found {
+				t.Errorf("StateMachine.ShortestPath() = %v, %v, want %v, %v", got, found, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+func TestStateMachine_AvailableEventsCtx(t *testing.T) {
+	allow := func(pass bool, err error) gofsm.Guard {
+		return func(ctx context.Context, from gofsm.State, event gofsm.Event) (bool, error) {
+			return pass, err
+		}
+	}
+	sm := gofsm.New("").
+		States(gofsm.StatesDef{"a": "", "b": ""}).
+		Events(gofsm.EventsDef{"open": "", "close": "", "fail": "", "retry": "", "off": ""}).
+		Transitions(
+			gofsm.Transition{From: "a", Event: "open", To: []gofsm.State{"b"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "a", Event: "close", To: []gofsm.State{"b"}, Action: gofsm.NoopAction, Guard: allow(false, nil)},
+			gofsm.Transition{From: "a", Event: "fail", To: []gofsm.State{"b"}, Action: gofsm.NoopAction, Guard: allow(true, errors.New("guard error"))},
+			gofsm.Transition{From: "a", Event: "retry", To: []gofsm.State{"b"}, Action: gofsm.NoopAction, Guard: allow(false, nil), Priority: 1},
+			gofsm.Transition{From: "a", Event: "retry", To: []gofsm.State{"a"}, Action: gofsm.NoopAction, Guard: allow(true, nil)},
+			gofsm.Transition{From: "a", Event: "off", To: []gofsm.State{"b"}, Action: gofsm.NoopAction},
+		)
+	sm.Disable("a", "off")
+
+	if got, want := sm.AvailableEvents("a"), []gofsm.Event{"close", "fail", "off", "open", "retry"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("StateMachine.AvailableEvents() = %v, want %v", got, want)
+	}
+	if got, want := sm.AvailableEventsCtx(context.TODO(), "a"), []gofsm.Event{"open", "retry"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("StateMachine.AvailableEventsCtx() = %v, want %v", got, want)
+	}
+	if got := sm.AvailableEventsCtx(context.TODO(), "b"); got != nil {
+		t.Errorf("StateMachine.AvailableEventsCtx() = %v, want nil", got)
+	}
+}
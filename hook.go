@@ -0,0 +1,32 @@
+package gofsm
+
+import "context"
+
+/**
+转换执行前的回调，在 OnExit 之前调用
+*/
+type BeforeHook func(ctx context.Context, from State, event Event)
+
+/**
+转换执行后的回调，在 OnEnter 或者错误处理之后调用，to 和 err 与 Trigger 的结果一致
+*/
+type AfterHook func(ctx context.Context, from State, event Event, to State, err error)
+
+/**
+注册每个转换执行前的回调，与事件处理器无关，所有匹配到的转换都会调用，按注册顺序执行
+没有匹配到转换（状态、事件不存在，没有定义转换，Guard 不满足）时不调用
+*/
+func (sm *StateMachine) BeforeEachTransition(hooks ...BeforeHook) *StateMachine {
+	sm.mutable()
+	sm.beforeHooks = append(sm.beforeHooks, hooks...)
+	return sm
+}
+
+/**
+注册每个转换执行后的回调，与事件处理器无关，所有匹配到的转换都会调用，按注册顺序执行
+*/
+func (sm *StateMachine) AfterEachTransition(hooks ...AfterHook) *StateMachine {
+	sm.mutable()
+	sm.afterHooks = append(sm.afterHooks, hooks...)
+	return sm
+}
@@ -0,0 +1,51 @@
+package gofsm_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestStateMachine_EachTransitionHooks(t *testing.T) {
+	var calls []string
+	failure := func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (gofsm.State, error) {
+		return gofsm.None, errors.New("failure")
+	}
+	processor := &enterProcessor{}
+	sm := newOrderMachine().
+		Transitions(gofsm.Transition{From: "paid", Event: "pay", To: []gofsm.State{"paid"}, Action: failure}).
+		Processor(processor).
+		BeforeEachTransition(func(ctx context.Context, from gofsm.State, event gofsm.Event) {
+			calls = append(calls, fmt.Sprintf("before %s %s", from, event))
+		}).
+		AfterEachTransition(func(ctx context.Context, from gofsm.State, event gofsm.Event, to gofsm.State, err error) {
+			calls = append(calls, fmt.Sprintf("after %s %s %s %v", from, event, to, err != nil))
+		})
+
+	tests := []struct {
+		name  string
+		from  gofsm.State
+		event gofsm.Event
+		calls []string
+	}{
+		{"Success", "new", "pay", []string{"before new pay", "after new pay paid false"}},
+		{"Action Failure", "paid", "pay", []string{"before paid pay", "after paid pay  true"}},
+		{"No Transition", "sent", "pay", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = nil
+			_, _ = sm.Trigger(context.TODO(), tt.from, tt.event)
+			if !reflect.DeepEqual(calls, tt.calls) {
+				t.Errorf("hooks = %v, want %v", calls, tt.calls)
+			}
+		})
+	}
+	if !reflect.DeepEqual(processor.entered, []gofsm.State{"paid"}) {
+		t.Errorf("OnEnter = %v, want [paid]", processor.entered)
+	}
+}
@@ -0,0 +1,148 @@
+package gofsm
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+/**
+状态图的 HTTP 服务，可以挂载到管理后台查看状态机定义
+	- GET /          PlantUML 脚本
+	- GET /svg       通过 PlantUML 在线服务生成的 SVG 图
+	- GET /spec.json 状态机拓扑定义
+*/
+func (sm *StateMachine) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		if !allowGet(w, r) {
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		script, _ := sm.sg.cachedPlantUML()
+		_, _ = io.WriteString(w, script)
+	})
+	mux.HandleFunc("/svg", func(w http.ResponseWriter, r *http.Request) {
+		if !allowGet(w, r) {
+			return
+		}
+		sm.serveSVG(w, r)
+	})
+	mux.HandleFunc("/spec.json", func(w http.ResponseWriter, r *http.Request) {
+		if !allowGet(w, r) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(sm.Spec())
+	})
+	return mux
+}
+
+func (sm *StateMachine) serveSVG(w http.ResponseWriter, r *http.Request) {
+	_, encoded := sm.sg.cachedPlantUML()
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, plantUMLServer+"/svg/~1"+encoded, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		http.Error(w, "PlantUML 服务返回 "+resp.Status, http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	_, _ = io.Copy(w, resp.Body)
+}
+
+/**
+实例的 HTTP 服务，返回 JSON
+	- GET /state   当前状态 {"state": "..."}
+	- GET /events  当前状态可以触发的事件 {"events": [...]}
+	- POST /fire   触发事件 {"event": "..."}，成功返回 {"state": "..."}
+触发失败返回 {"error": "...", "code": "..."}，状态码：事件不存在 404，没有转换或条件不满足 409，结束状态 422
+*/
+func InstanceHandler(i *Instance) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/state", func(w http.ResponseWriter, r *http.Request) {
+		if !allowGet(w, r) {
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"state": i.Current()})
+	})
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		if !allowGet(w, r) {
+			return
+		}
+		events := i.sm.AvailableEvents(i.Current())
+		if events == nil {
+			events = []Event{}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"events": events})
+	})
+	mux.HandleFunc("/fire", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			Event Event `json:"event"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": err.Error(), "code": "bad_request"})
+			return
+		}
+		state, err := i.Fire(r.Context(), req.Event)
+		if err != nil {
+			status, code := fireErrorStatus(err)
+			writeJSON(w, status, map[string]interface{}{"error": err.Error(), "code": code, "state": state})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"state": state})
+	})
+	return mux
+}
+
+/**
+触发错误对应的 HTTP 状态码和错误代码
+*/
+func fireErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrUnknownEvent):
+		return http.StatusNotFound, "unknown_event"
+	case errors.Is(err, ErrNoTransition):
+		return http.StatusConflict, "no_transition"
+	case errors.Is(err, ErrGuardRejected):
+		return http.StatusConflict, "guard_rejected"
+	case errors.Is(err, ErrBudgetExhausted):
+		return http.StatusConflict, "budget_exhausted"
+	case errors.Is(err, ErrTerminalState):
+		return http.StatusUnprocessableEntity, "terminal_state"
+	}
+	return http.StatusInternalServerError, "action_failure"
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func allowGet(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return true
+	}
+	w.Header().Set("Allow", "GET, HEAD")
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	return false
+}
@@ -0,0 +1,118 @@
+package gofsm
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStateMachine_Handler(t *testing.T) {
+	plantUML := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/svg/~1") {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("<svg></svg>"))
+	}))
+	defer plantUML.Close()
+	server := plantUMLServer
+	plantUMLServer = plantUML.URL
+	defer func() { plantUMLServer = server }()
+
+	sm := New("handler").
+		States(StatesDef{"a": "A", "b": "B"}).
+		Events(EventsDef{"go": ""}).
+		Transitions(Transition{From: "a", Event: "go", To: []State{"b"}, Action: NoopAction})
+	handler := sm.Handler()
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		code        int
+		contentType string
+		want        string
+	}{
+		{"Script", http.MethodGet, "/", http.StatusOK, "text/plain; charset=utf-8", "a --> b : (go)"},
+		{"Svg", http.MethodGet, "/svg", http.StatusOK, "image/svg+xml", "<svg></svg>"},
+		{"Spec", http.MethodGet, "/spec.json", http.StatusOK, "application/json", `"name":"handler"`},
+		{"Not Found", http.MethodGet, "/other", http.StatusNotFound, "", ""},
+		{"Method Not Allowed", http.MethodPost, "/", http.StatusMethodNotAllowed, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.code {
+				t.Fatalf("Handler() code = %v, want %v", w.Code, tt.code)
+			}
+			if tt.contentType != "" && w.Header().Get("Content-Type") != tt.contentType {
+				t.Errorf("Handler() Content-Type = %v, want %v", w.Header().Get("Content-Type"), tt.contentType)
+			}
+			body, _ := ioutil.ReadAll(w.Body)
+			if !strings.Contains(string(body), tt.want) {
+				t.Errorf("Handler() body = %s, want contains %s", body, tt.want)
+			}
+		})
+	}
+
+	t.Run("Spec Decode", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/spec.json", nil))
+		spec := &Spec{}
+		if err := json.NewDecoder(w.Body).Decode(spec); err != nil {
+			t.Fatal(err)
+		}
+		if len(spec.Transitions) != 1 || spec.Transitions[0].From != "a" || spec.States["b"] != "B" {
+			t.Errorf("Handler() spec = %+v", spec)
+		}
+	})
+}
+
+func TestInstanceHandler(t *testing.T) {
+	sm := New("order").
+		States(StatesDef{"new": "", "paid": "", "sent": ""}).
+		Events(EventsDef{"pay": "", "send": ""}).
+		End([]State{"sent"}).
+		FreezeTerminal(true).
+		Transitions(
+			Transition{From: "new", Event: "pay", To: []State{"paid"}, Action: NoopAction},
+			Transition{From: "paid", Event: "send", To: []State{"sent"}, Action: NoopAction},
+		)
+	handler := InstanceHandler(sm.NewInstance("new"))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		code   int
+		want   string
+	}{
+		{"State", http.MethodGet, "/state", "", http.StatusOK, `{"state":"new"}`},
+		{"Events", http.MethodGet, "/events", "", http.StatusOK, `{"events":["pay"]}`},
+		{"Unknown Event", http.MethodPost, "/fire", `{"event":"refund"}`, http.StatusNotFound, `"code":"unknown_event"`},
+		{"No Transition", http.MethodPost, "/fire", `{"event":"send"}`, http.StatusConflict, `"code":"no_transition"`},
+		{"Bad Request", http.MethodPost, "/fire", `{`, http.StatusBadRequest, `"code":"bad_request"`},
+		{"Fire", http.MethodPost, "/fire", `{"event":"pay"}`, http.StatusOK, `{"state":"paid"}`},
+		{"Fire Again", http.MethodPost, "/fire", `{"event":"send"}`, http.StatusOK, `{"state":"sent"}`},
+		{"Terminal", http.MethodPost, "/fire", `{"event":"send"}`, http.StatusUnprocessableEntity, `"code":"terminal_state"`},
+		{"Events Empty", http.MethodGet, "/events", "", http.StatusOK, `{"events":[]}`},
+		{"Fire Method", http.MethodGet, "/fire", "", http.StatusMethodNotAllowed, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+			if rec.Code != tt.code {
+				t.Errorf("code = %v, want %v", rec.Code, tt.code)
+			}
+			if !strings.Contains(rec.Body.String(), tt.want) {
+				t.Errorf("body = %v, want contains %v", rec.Body.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package gofsm
+
+import (
+	"context"
+	"fmt"
+	"qiniupkg.com/x/errors.v7"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/**
+历史记录类型
+*/
+type RecordKind string
+
+const (
+	RecordTransition RecordKind = "transition"
+	RecordReset      RecordKind = "reset"
+)
+
+/**
+实例状态变化记录
+	- Time: 状态变化的时间
+	- Err: Action 执行失败进入错误状态时的错误，其他情况为 nil
+*/
+type Record struct {
+	Kind  RecordKind
+	From  State
+	Event Event
+	To    State
+	Time  time.Time
+	Err   error
+}
+
+/**
+状态机实例
+状态机只描述状态图，实例保存当前所处状态和状态变化历史，并发安全
+*/
+type Instance struct {
+	mu       sync.Mutex
+	sm       *StateMachine
+	current  State
+	history  []Record
+	queue    chan Event
+	observer Observer
+	budget   int
+	steps    int
+
+	onComplete func(ctx context.Context, final State)
+	completed  bool // 已经进入过结束状态
+}
+
+/**
+创建一个处于 current 状态的实例
+创建实例后状态机不能再修改，需要修改时先 Clone
+*/
+func (sm *StateMachine) NewInstance(current State) *Instance {
+	atomic.StoreInt32(&sm.inUse, 1)
+	return &Instance{sm: sm, current: sm.normState(current), queue: make(chan Event, queueSize)}
+}
+
+/**
+当前状态
+*/
+func (i *Instance) Current() State {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.current
+}
+
+/**
+状态变化历史
+*/
+func (i *Instance) History() []Record {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return append([]Record(nil), i.history...)
+}
+
+/**
+在当前状态上触发事件，成功后实例进入新的状态
+Action 执行失败时，如果 OnActionFailure 返回了恢复状态或者状态机设置了错误状态，实例进入该状态
+没有目标状态（返回 None）时实例保持当前状态
+*/
+func (i *Instance) Fire(ctx context.Context, event Event) (State, error) {
+	i.mu.Lock()
+	state, err := i.fire(ctx, event, triggerOptions{})
+	complete := i.completion(state, err)
+	i.mu.Unlock()
+	if complete != nil {
+		complete(ctx, state)
+	}
+	return state, err
+}
+
+func (i *Instance) fire(ctx context.Context, event Event, opts triggerOptions) (State, error) {
+	if i.budget > 0 && i.steps >= i.budget {
+		return i.current, fmt.Errorf("%w [%v --%v--> ???]", ErrBudgetExhausted, i.current, event)
+	}
+	result, err := i.sm.triggerX(ctx, i.current, event, opts)
+	to := result.State
+	if err != nil && !result.Recovered {
+		return i.current, err
+	}
+	if err == nil {
+		i.steps++
+	}
+	if to == None {
+		to = i.current
+	}
+	i.history = append(i.history, Record{Kind: RecordTransition, From: i.current, Event: event, To: to, Time: time.Now(), Err: err})
+	i.current = to
+	return to, err
+}
+
+/**
+设置实例进入结束状态时的回调，在 OnEnter 之后、Fire 返回之前调用
+每个实例最多调用一次，Reset 后再次进入结束状态也不会调用；回调中可以继续调用实例的方法
+*/
+func (i *Instance) OnComplete(fn func(ctx context.Context, final State)) *Instance {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.onComplete = fn
+	return i
+}
+
+/**
+转换成功进入结束状态时返回需要调用的 OnComplete 回调，只返回一次，需要持有锁
+*/
+func (i *Instance) completion(state State, err error) func(ctx context.Context, final State) {
+	if err != nil || i.completed || !i.sm.sg.isTerminal(state) {
+		return nil
+	}
+	i.completed = true
+	return i.onComplete
+}
+
+/**
+复制实例的当前状态、历史记录和转换次数限制，得到共享同一个状态机的独立实例，用于试探性执行
+不复制事件队列中的事件以及 Observe、OnComplete 设置的回调，修改复制的实例不影响原实例
+*/
+func (i *Instance) Fork() *Instance {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return &Instance{
+		sm:        i.sm,
+		current:   i.current,
+		history:   append([]Record(nil), i.history...),
+		queue:     make(chan Event, queueSize),
+		budget:    i.budget,
+		steps:     i.steps,
+		completed: i.completed,
+	}
+}
+
+/**
+限制实例成功转换的次数，从调用时开始计数，用完后 Fire 返回 ErrBudgetExhausted
+用于防止 Run 驱动的循环状态机无限转换，n <= 0 时不限制
+*/
+func (i *Instance) SetStepBudget(n int) *Instance {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.budget, i.steps = n, 0
+	return i
+}
+
+/**
+重置实例到开始状态
+状态机有多个开始状态时需要通过 start 指定其中一个
+*/
+func (i *Instance) Reset(ctx context.Context, start ...State) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	to, err := i.sm.sg.resetState(i.sm.normStateList(start)...)
+	if err != nil {
+		return err
+	}
+
+	i.sm.debugf("reset [%s] to [%s]", i.current, to)
+	_ = i.sm.machineProcessor().OnEnter(ctx, i.current, None, to)
+	i.history = append(i.history, Record{Kind: RecordReset, From: i.current, To: to, Time: time.Now()})
+	i.current = to
+	return nil
+}
+
+func (sg *stateGraph) resetState(start ...State) (State, error) {
+	switch {
+	case len(start) > 1:
+		return None, errors.New("只能指定一个开始状态")
+	case len(start) == 1:
+		for _, s := range sg.start {
+			if s == start[0] {
+				return s, nil
+			}
+		}
+		return None, errors.New(fmt.Sprintf("%s 不是开始状态", start[0]))
+	case len(sg.start) == 0:
+		return None, errors.New("状态机没有定义开始状态")
+	case len(sg.start) > 1:
+		return None, errors.New(fmt.Sprintf("状态机有多个开始状态 %v，需要指定其中一个", sg.start))
+	}
+	return sg.start[0], nil
+}
@@ -0,0 +1,278 @@
+package gofsm_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/threeq/gofsm"
+)
+
+type enterProcessor struct {
+	gofsm.DefaultProcessor
+	entered []gofsm.State
+}
+
+func (p *enterProcessor) OnEnter(ctx context.Context, state gofsm.State) error {
+	p.entered = append(p.entered, state)
+	return nil
+}
+
+func newOrderMachine() *gofsm.StateMachine {
+	return gofsm.New("order").
+		States(gofsm.StatesDef{"new": "新建", "paid": "已支付", "sent": "已发货", "imported": "导入"}).
+		Events(gofsm.EventsDef{"pay": "支付", "send": "发货"}).
+		Start([]gofsm.State{"new"}).
+		End([]gofsm.State{"sent"}).
+		Transitions(
+			gofsm.Transition{From: "new", Event: "pay", To: []gofsm.State{"paid"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "paid", Event: "send", To: []gofsm.State{"sent"}, Action: gofsm.NoopAction},
+		)
+}
+
+func withoutTime(history []gofsm.Record) []gofsm.Record {
+	for n := range history {
+		history[n].Time = time.Time{}
+	}
+	return history
+}
+
+func TestInstance_Fire(t *testing.T) {
+	i := newOrderMachine().NewInstance("new")
+
+	if got, err := i.Fire(context.TODO(), "pay"); err != nil || got != "paid" {
+		t.Fatalf("Instance.Fire() = %v, %v, want paid", got, err)
+	}
+	if _, err := i.Fire(context.TODO(), "pay"); err == nil {
+		t.Fatalf("Instance.Fire() want error")
+	}
+	if got := i.Current(); got != "paid" {
+		t.Errorf("Instance.Current() = %v, want paid", got)
+	}
+	want := []gofsm.Record{{Kind: gofsm.RecordTransition, From: "new", Event: "pay", To: "paid"}}
+	if got := withoutTime(i.History()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Instance.History() = %v, want %v", got, want)
+	}
+}
+
+func TestInstance_Reset(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   []gofsm.State
+		args    []gofsm.State
+		want    gofsm.State
+		wantErr bool
+	}{
+		{"Single Start", []gofsm.State{"new"}, nil, "new", false},
+		{"No Start", nil, nil, "paid", true},
+		{"Multiple Start", []gofsm.State{"new", "imported"}, nil, "paid", true},
+		{"Multiple Start Specified", []gofsm.State{"new", "imported"}, []gofsm.State{"imported"}, "imported", false},
+		{"Not Start", []gofsm.State{"new"}, []gofsm.State{"sent"}, "paid", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := &enterProcessor{}
+			i := newOrderMachine().Start(tt.start).Processor(processor).NewInstance("new")
+			_, _ = i.Fire(context.TODO(), "pay")
+			processor.entered = nil
+
+			err := i.Reset(context.TODO(), tt.args...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Instance.Reset() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := i.Current(); got != tt.want {
+				t.Errorf("Instance.Current() = %v, want %v", got, tt.want)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(processor.entered, []gofsm.State{tt.want}) {
+				t.Errorf("OnEnter = %v, want %v", processor.entered, tt.want)
+			}
+			history := i.History()
+			want := gofsm.Record{Kind: gofsm.RecordReset, From: "paid", To: tt.want}
+			if got := withoutTime(history)[len(history)-1]; got != want {
+				t.Errorf("Instance.History() last = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestInstance_Reset_Concurrent(t *testing.T) {
+	i := newOrderMachine().NewInstance("new")
+	var wg sync.WaitGroup
+	for n := 0; n < 50; n++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, _ = i.Fire(context.TODO(), "pay")
+		}()
+		go func() {
+			defer wg.Done()
+			_ = i.Reset(context.TODO())
+		}()
+	}
+	wg.Wait()
+	for _, r := range i.History() {
+		if r.Kind == gofsm.RecordTransition && (r.From != "new" || r.To != "paid") {
+			t.Errorf("Instance.History() corrupted record %v", r)
+		}
+	}
+}
+
+func TestInstance_Fire_ErrorState(t *testing.T) {
+	failure := func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (gofsm.State, error) {
+		return "", errors.New("action error")
+	}
+	sm := newOrderMachine().
+		States(gofsm.StatesDef{"new": "", "paid": "", "sent": "", "error": ""}).
+		Transitions(gofsm.Transition{From: "paid", Event: "pay", To: []gofsm.State{"paid"}, Action: failure})
+
+	tests := []struct {
+		name       string
+		errorState gofsm.State
+		want       gofsm.State
+	}{
+		{"Stay", gofsm.None, "paid"},
+		{"Move To Error State", "error", "error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := sm.Clone().ErrorState(tt.errorState).NewInstance("paid")
+			if _, err := i.Fire(context.TODO(), "pay"); err == nil {
+				t.Fatalf("Instance.Fire() want error")
+			}
+			if got := i.Current(); got != tt.want {
+				t.Errorf("Instance.Current() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstance_Fire_NoTarget(t *testing.T) {
+	i := newOrderMachine().
+		Transitions(gofsm.Transition{From: "new", Event: "send", Action: gofsm.NoopAction}).
+		NewInstance("new")
+	if got, err := i.Fire(context.TODO(), "send"); err != nil || got != "new" {
+		t.Errorf("Instance.Fire() = %q, %v, want new", got, err)
+	}
+}
+
+func TestInstance_SetStepBudget(t *testing.T) {
+	sm := newOrderMachine().Transitions(
+		gofsm.Transition{From: "paid", Event: "pay", To: []gofsm.State{"new"}, Action: gofsm.NoopAction},
+	)
+	tests := []struct {
+		name   string
+		budget int
+		fires  int
+		want   gofsm.State
+		err    error
+	}{
+		{"Unlimited", 0, 5, "paid", nil},
+		{"Within Budget", 3, 3, "paid", nil},
+		{"Exhausted", 2, 3, "new", gofsm.ErrBudgetExhausted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := sm.NewInstance("new").SetStepBudget(tt.budget)
+			var err error
+			for n := 0; n < tt.fires; n++ {
+				_, err = i.Fire(context.TODO(), "pay")
+			}
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Errorf("Instance.Fire() error = %v, want %v", err, tt.err)
+			}
+			if got := i.Current(); got != tt.want {
+				t.Errorf("Instance.Current() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstance_Fork(t *testing.T) {
+	sm := newOrderMachine().Transitions(
+		gofsm.Transition{From: "paid", Event: "pay", To: []gofsm.State{"new"}, Action: gofsm.NoopAction},
+	)
+	i := sm.NewInstance("new").SetStepBudget(2)
+	_, _ = i.Fire(context.TODO(), "pay")
+
+	fork := i.Fork()
+	if got := fork.Current(); got != "paid" || !reflect.DeepEqual(fork.History(), i.History()) {
+		t.Fatalf("Instance.Fork() current = %v, history = %v", got, fork.History())
+	}
+	if got, err := fork.Fire(context.TODO(), "send"); err != nil || got != "sent" {
+		t.Fatalf("forked Instance.Fire() = %v, %v, want sent", got, err)
+	}
+	if _, err := fork.Fire(context.TODO(), "pay"); !errors.Is(err, gofsm.ErrBudgetExhausted) {
+		t.Errorf("forked Instance.Fire() error = %v, want %v", err, gofsm.ErrBudgetExhausted)
+	}
+	if got := i.Current(); got != "paid" || len(i.History()) != 1 {
+		t.Errorf("original Instance.Current() = %v, history = %v, want unchanged", got, i.History())
+	}
+
+	var wg sync.WaitGroup
+	for n := 0; n < 10; n++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, _ = i.Fire(context.TODO(), "pay")
+		}()
+		go func() {
+			defer wg.Done()
+			_ = i.Fork().Current()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestInstance_OnComplete(t *testing.T) {
+	sm := newOrderMachine().Transitions(
+		gofsm.Transition{From: "sent", Event: "send", To: []gofsm.State{"sent"}, Action: gofsm.NoopAction},
+	)
+
+	var calls []gofsm.State
+	var i *gofsm.Instance
+	i = sm.NewInstance("new").OnComplete(func(ctx context.Context, final gofsm.State) {
+		calls = append(calls, final, i.Current())
+	})
+	for _, event := range []gofsm.Event{"pay", "send", "send"} {
+		_, _ = i.Fire(context.TODO(), event)
+	}
+	_ = i.Reset(context.TODO())
+	for _, event := range []gofsm.Event{"pay", "send"} {
+		_, _ = i.Fire(context.TODO(), event)
+	}
+	if want := []gofsm.State{"sent", "sent"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("OnComplete calls = %v, want %v", calls, want)
+	}
+}
+
+func TestInstance_OnComplete_Concurrent(t *testing.T) {
+	sm := newOrderMachine().Transitions(
+		gofsm.Transition{From: "sent", Event: "send", To: []gofsm.State{"sent"}, Action: gofsm.NoopAction},
+	)
+	var mu sync.Mutex
+	calls := 0
+	i := sm.NewInstance("paid").OnComplete(func(ctx context.Context, final gofsm.State) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+	})
+
+	var wg sync.WaitGroup
+	for n := 0; n < 50; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = i.Fire(context.TODO(), "send")
+		}()
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("OnComplete calls = %v, want 1", calls)
+	}
+}
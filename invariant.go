@@ -0,0 +1,61 @@
+package gofsm
+
+import (
+	"context"
+	"fmt"
+)
+
+/**
+状态不变式不满足时返回的错误，可以通过 errors.Is(err, ErrInvariant) 判断，Unwrap 返回不变式返回的错误
+*/
+type InvariantError struct {
+	State State
+	Err   error
+}
+
+func (e *InvariantError) Error() string {
+	return fmt.Sprintf("%v [%s]: %v", ErrInvariant, e.State, e.Err)
+}
+
+func (e *InvariantError) Unwrap() error {
+	return e.Err
+}
+
+func (e *InvariantError) Is(target error) bool {
+	return target == ErrInvariant
+}
+
+/**
+状态不变式，Trigger 得到目标状态之后、OnEnter 之前按注册顺序检查，同一个状态可以注册多个
+返回错误时转换中止，与 Action 出错一样调用 OnActionFailure，设置了错误状态时进入错误状态
+进入错误状态和 Reset 时不检查
+*/
+func (sm *StateMachine) Invariant(state State, fn func(ctx context.Context) error) *StateMachine {
+	sm.mutable()
+	if sm.invariants == nil {
+		sm.invariants = map[State][]func(ctx context.Context) error{}
+	}
+	state = sm.normState(state)
+	sm.invariants[state] = append(sm.invariants[state], fn)
+	return sm
+}
+
+func (sm *StateMachine) checkInvariants(ctx context.Context, state State) error {
+	for _, fn := range sm.invariants[state] {
+		if err := sm.safely(func() error { return fn(ctx) }); err != nil {
+			return &InvariantError{State: state, Err: err}
+		}
+	}
+	return nil
+}
+
+func copyInvariants(invariants map[State][]func(ctx context.Context) error) map[State][]func(ctx context.Context) error {
+	if invariants == nil {
+		return nil
+	}
+	c := make(map[State][]func(ctx context.Context) error, len(invariants))
+	for state, fns := range invariants {
+		c[state] = append([]func(ctx context.Context) error(nil), fns...)
+	}
+	return c
+}
@@ -0,0 +1,71 @@
+package gofsm_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+type invariantProcessor struct {
+	enterProcessor
+	failures []error
+}
+
+func (p *invariantProcessor) OnActionFailure(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State, err error) error {
+	p.failures = append(p.failures, err)
+	return nil
+}
+
+func TestStateMachine_Invariant(t *testing.T) {
+	errNoPayment := errors.New("no payment")
+	requirePayment := func(ctx context.Context) error {
+		if ctx.Value("payment") == nil {
+			return errNoPayment
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		errorState gofsm.State
+		want       gofsm.State
+		entered    []gofsm.State
+		wantErr    bool
+	}{
+		{"Holds", context.WithValue(context.TODO(), "payment", 1), gofsm.None, "paid", []gofsm.State{"paid"}, false},
+		{"Violated", context.TODO(), gofsm.None, "new", nil, true},
+		{"Violated Error State", context.TODO(), "imported", "imported", []gofsm.State{"imported"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := &invariantProcessor{}
+			i := newOrderMachine().Processor(processor).ErrorState(tt.errorState).
+				Invariant("paid", requirePayment).NewInstance("new")
+			_, err := i.Fire(tt.ctx, "pay")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Instance.Fire() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := i.Current(); got != tt.want {
+				t.Errorf("Instance.Current() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(processor.entered, tt.entered) {
+				t.Errorf("entered = %v, want %v", processor.entered, tt.entered)
+			}
+			if !tt.wantErr {
+				return
+			}
+			var invariantErr *gofsm.InvariantError
+			if !errors.Is(err, gofsm.ErrInvariant) || !errors.Is(err, errNoPayment) ||
+				!errors.As(err, &invariantErr) || invariantErr.State != "paid" {
+				t.Errorf("Instance.Fire() error = %v, want invariant error", err)
+			}
+			if len(processor.failures) != 1 || processor.failures[0] != err {
+				t.Errorf("OnActionFailure errors = %v, want %v", processor.failures, err)
+			}
+		})
+	}
+}
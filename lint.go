@@ -0,0 +1,177 @@
+package gofsm
+
+import (
+	"fmt"
+	"sort"
+)
+
+/**
+Lint 检查的规则编号
+*/
+const (
+	LintStartIsEnd    = "start-is-end"   // 状态同时是开始状态和结束状态
+	LintEndOutgoing   = "end-outgoing"   // 结束状态有出边
+	LintStartIncoming = "start-incoming" // 开始状态有入边
+	LintFanOut        = "fan-out"        // 同一个事件的目标状态数量超过 WarnFanOut 设置的阈值
+	LintDeadEvent     = "dead-event"     // 事件只能从不可达的状态触发
+	LintOrphanEvent   = "orphan-event"   // 事件没有从非结束状态出发的转换
+)
+
+/**
+Lint 发现的问题，不一定是错误，由调用者根据 Code 决定如何处理
+*/
+type Warning struct {
+	Code    string `json:"code"`
+	State   State  `json:"state,omitempty"`
+	Event   Event  `json:"event,omitempty"`
+	Message string `json:"message"`
+}
+
+func (w Warning) String() string {
+	return w.Code + ": " + w.Message
+}
+
+/**
+检查状态机定义中可疑的地方，结果按 Code、State、Event 排序
+	- start-is-end: 状态同时是开始状态和结束状态
+	- end-outgoing: 结束状态有出边
+	- start-incoming: 开始状态有入边（循环流程中可能是有意的）
+	- fan-out: 设置 WarnFanOut 时，同一个事件的目标状态数量超过阈值
+	- dead-event: 事件定义了转换，但是所有转换的 From 都无法从开始状态到达，没有定义开始状态时不检查
+	- orphan-event: Events 中的事件没有从非结束状态出发的转换，参考 OrphanEvents
+*/
+func (sm *StateMachine) Lint() []Warning {
+	sg := sm.sg
+	var warnings []Warning
+
+	start := map[State]bool{}
+	for _, state := range sg.start {
+		start[state] = true
+	}
+	for _, state := range removeRepByMap(sg.end) {
+		if start[state] {
+			warnings = append(warnings, Warning{Code: LintStartIsEnd, State: state,
+				Message: fmt.Sprintf("状态 %s 同时是开始状态和结束状态", state)})
+		}
+		if len(sg.scanEvents(state, false)) > 0 {
+			warnings = append(warnings, Warning{Code: LintEndOutgoing, State: state,
+				Message: fmt.Sprintf("结束状态 %s 有出边", state)})
+		}
+	}
+
+	incoming := map[State]bool{}
+	sg.each(func(transfer *Transition) bool {
+		for _, to := range transfer.To {
+			if start[to] && !incoming[to] {
+				incoming[to] = true
+				warnings = append(warnings, Warning{Code: LintStartIncoming, State: to,
+					Message: fmt.Sprintf("开始状态 %s 有入边", to)})
+			}
+		}
+		return true
+	})
+
+	for _, event := range sg.deadEvents() {
+		warnings = append(warnings, Warning{Code: LintDeadEvent, Event: event,
+			Message: fmt.Sprintf("事件 %s 只能从不可达的状态触发", event)})
+	}
+
+	for _, event := range sm.OrphanEvents() {
+		warnings = append(warnings, Warning{Code: LintOrphanEvent, Event: event,
+			Message: fmt.Sprintf("事件 %s 没有从非结束状态出发的转换，不可能被触发", event)})
+	}
+
+	sm.fanOuts(func(from State, event Event, targets int) {
+		warnings = append(warnings, Warning{Code: LintFanOut, State: from, Event: event,
+			Message: fmt.Sprintf("状态 %s 上的事件 %s 有 %d 个目标状态，超过 %d", from, event, targets, sm.fanOut)})
+	})
+
+	sort.SliceStable(warnings, func(i, j int) bool {
+		a, b := warnings[i], warnings[j]
+		if a.Code != b.Code {
+			return a.Code < b.Code
+		}
+		if a.State != b.State {
+			return a.State < b.State
+		}
+		return a.Event < b.Event
+	})
+	return warnings
+}
+
+/**
+同一个状态上同一个事件的目标状态数量超过 threshold 时 Lint 和 Validate 报告问题，threshold <= 0 时不检查
+DFA 设置为 1 可以发现 Transitions 合并意外产生的 NFA 转换
+*/
+func (sm *StateMachine) WarnFanOut(threshold int) *StateMachine {
+	sm.mutable()
+	sm.fanOut = threshold
+	return sm
+}
+
+/**
+目标状态数量超过 WarnFanOut 阈值的转换，按 from、event 排序
+*/
+func (sm *StateMachine) fanOuts(fn func(from State, event Event, targets int)) {
+	if sm.fanOut <= 0 {
+		return
+	}
+	for _, from := range sm.sg.sortedFroms() {
+		events := sm.sg.transitions[from]
+		for _, event := range sortedTransitionEvents(events) {
+			if n := distinctTargets(events[event]); n > sm.fanOut {
+				fn(from, event, n)
+			}
+		}
+	}
+}
+
+/**
+只在不可达状态上有转换的事件，不包含 None，按名称排序
+*/
+func (sg *stateGraph) deadEvents() []Event {
+	reachable := sg.reachableFromRoots()
+	if reachable == nil {
+		return nil
+	}
+	reachable[Start] = true
+	used, live := map[Event]bool{}, map[Event]bool{}
+	sg.each(func(transfer *Transition) bool {
+		used[transfer.Event] = true
+		if reachable[transfer.From] {
+			live[transfer.Event] = true
+		}
+		return true
+	})
+	var dead []Event
+	for event := range used {
+		if !live[event] && event != None {
+			dead = append(dead, event)
+		}
+	}
+	sort.Slice(dead, func(i, j int) bool { return dead[i] < dead[j] })
+	return dead
+}
+
+/**
+Events 中定义但是没有从任何非结束状态出发的转换的事件，按名称排序
+包括没有任何转换的事件，以及只能从结束状态触发的事件（FreezeTerminal 时这些事件永远不能触发）
+*/
+func (sm *StateMachine) OrphanEvents() []Event {
+	sg := sm.sg
+	handled := map[Event]bool{}
+	sg.each(func(transfer *Transition) bool {
+		if !sg.isTerminal(transfer.From) {
+			handled[transfer.Event] = true
+		}
+		return true
+	})
+	var orphans []Event
+	for event := range sg.events {
+		if !handled[event] && event != None {
+			orphans = append(orphans, event)
+		}
+	}
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i] < orphans[j] })
+	return orphans
+}
@@ -0,0 +1,77 @@
+package gofsm_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/threeq/gofsm"
+)
+
+func lintCodes(warnings []gofsm.Warning) []string {
+	var codes []string
+	for _, w := range warnings {
+		codes = append(codes, w.Code+" "+string(w.State)+string(w.Event))
+	}
+	return codes
+}
+
+func TestStateMachine_Lint(t *testing.T) {
+	tests := []struct {
+		name string
+		sm   *gofsm.StateMachine
+		want []string
+	}{
+		{"Clean", newOrderMachine(), nil},
+		{"Start Is End", newOrderMachine().End([]gofsm.State{"new", "sent"}),
+			[]string{"end-outgoing new", "orphan-event pay", "start-is-end new"}},
+		{"End Outgoing", newOrderMachine().Transitions(
+			gofsm.Transition{From: "sent", Event: "send", To: []gofsm.State{"imported"}, Action: gofsm.NoopAction},
+		), []string{"end-outgoing sent"}},
+		{"Start Incoming", newOrderMachine().Transitions(
+			gofsm.Transition{From: "paid", Event: "pay", To: []gofsm.State{"new"}, Action: gofsm.NoopAction},
+		), []string{"start-incoming new"}},
+		{"Fan Out", newOrderMachine().WarnFanOut(1).Transitions(
+			gofsm.Transition{From: "new", Event: "pay", To: []gofsm.State{"imported"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "paid", Event: "send", To: []gofsm.State{"sent"}, Action: gofsm.NoopAction},
+		), []string{"fan-out newpay"}},
+		{"Dead Event", newOrderMachine().Transitions(
+			gofsm.Transition{From: "imported", Event: "import", To: []gofsm.State{"paid"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "imported", Event: "pay", To: []gofsm.State{"paid"}, Action: gofsm.NoopAction},
+		), []string{"dead-event import"}},
+		{"Error State Events", newOrderMachine().ErrorState("imported").Transitions(
+			gofsm.Transition{From: "imported", Event: "import", To: []gofsm.State{"paid"}, Action: gofsm.NoopAction},
+		), nil},
+		{"After Target Events", newOrderMachine().After("paid", time.Hour, "imported", nil).Transitions(
+			gofsm.Transition{From: "imported", Event: "import", To: []gofsm.State{"paid"}, Action: gofsm.NoopAction},
+		), nil},
+		{"Dead Event Without Start", gofsm.New("").Transitions(
+			gofsm.Transition{From: "a", Event: "e1", To: []gofsm.State{"b"}, Action: gofsm.NoopAction},
+		), nil},
+		{"Orphan Event", newOrderMachine().Events(gofsm.EventsDef{"pay": "", "send": "", "refund": "", "reopen": ""}).Transitions(
+			gofsm.Transition{From: "sent", Event: "reopen", To: []gofsm.State{"new"}, Action: gofsm.NoopAction},
+		), []string{"end-outgoing sent", "orphan-event refund", "orphan-event reopen", "start-incoming new"}},
+		{"Fan Out Within Threshold", newOrderMachine().WarnFanOut(2).Transitions(
+			gofsm.Transition{From: "new", Event: "pay", To: []gofsm.State{"imported"}, Action: gofsm.NoopAction},
+		), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lintCodes(tt.sm.Lint()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StateMachine.Lint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStateMachine_OrphanEvents(t *testing.T) {
+	sm := newOrderMachine().
+		Events(gofsm.EventsDef{"pay": "", "send": "", "refund": "", "reopen": ""}).
+		Transitions(gofsm.Transition{From: "sent", Event: "reopen", To: []gofsm.State{"new"}, Action: gofsm.NoopAction})
+	if got, want := sm.OrphanEvents(), []gofsm.Event{"refund", "reopen"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("StateMachine.OrphanEvents() = %v, want %v", got, want)
+	}
+	if got := newOrderMachine().OrphanEvents(); got != nil {
+		t.Errorf("StateMachine.OrphanEvents() = %v, want nil", got)
+	}
+}
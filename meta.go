@@ -0,0 +1,50 @@
+package gofsm
+
+import "strings"
+
+/**
+状态元数据中表示图中背景色的键，例如 "LightBlue"、"#FFAA00"
+*/
+const MetaColor = "color"
+
+/**
+设置状态的元数据（SLA、负责团队、颜色等），与 StatesDef 中的描述一起保存，重复设置时覆盖
+Show 使用 MetaColor 设置状态的背景色
+*/
+func (sm *StateMachine) StateMeta(state State, meta map[string]string) *StateMachine {
+	sm.mutable()
+	if sm.sg.meta == nil {
+		sm.sg.meta = map[State]map[string]string{}
+	}
+	sm.sg.meta[sm.normState(state)] = copyMeta(meta)
+	return sm
+}
+
+/**
+状态的元数据，没有设置时返回 nil，返回值可以修改
+*/
+func (sm *StateMachine) Meta(state State) map[string]string {
+	return copyMeta(sm.sg.meta[sm.normState(state)])
+}
+
+func copyMeta(meta map[string]string) map[string]string {
+	if meta == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(meta))
+	for key, value := range meta {
+		copied[key] = value
+	}
+	return copied
+}
+
+/**
+状态在 PlantUML 中的背景色，没有设置时为空
+*/
+func (sg *stateGraph) stateColor(state State) string {
+	color := sg.meta[state][MetaColor]
+	if color == "" {
+		return ""
+	}
+	return "#" + strings.TrimPrefix(color, "#")
+}
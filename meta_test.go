@@ -0,0 +1,58 @@
+package gofsm_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestStateMachine_StateMeta(t *testing.T) {
+	meta := map[string]string{"team": "payments", gofsm.MetaColor: "LightBlue", "sla": "1h"}
+	sm := newOrderMachine().
+		StateMeta("paid", meta).
+		StateMeta("sent", map[string]string{gofsm.MetaColor: "#FFAA00"})
+	meta["team"] = "changed"
+
+	tests := []struct {
+		name  string
+		state gofsm.State
+		want  map[string]string
+	}{
+		{"With Meta", "paid", map[string]string{"team": "payments", gofsm.MetaColor: "LightBlue", "sla": "1h"}},
+		{"Without Meta", "new", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sm.Meta(tt.state)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StateMachine.Meta() = %v, want %v", got, tt.want)
+			}
+			if got != nil {
+				got["team"] = "modified"
+				if sm.Meta(tt.state)["team"] == "modified" {
+					t.Errorf("StateMachine.Meta() returned internal map")
+				}
+			}
+		})
+	}
+
+	show := plantUMLScript(t, sm)
+	for _, want := range []string{`state "paid" as paid #LightBlue :已支付`, `state "sent" as sent #FFAA00 :已发货`, `state "new" as new  :新建`} {
+		if !strings.Contains(show, want) {
+			t.Errorf("PlantUML script = %v, want contains %q", show, want)
+		}
+	}
+
+	clone := sm.Clone()
+	if err := clone.RenameState("paid", "charged"); err != nil {
+		t.Fatalf("RenameState() error = %v", err)
+	}
+	if got := clone.Meta("charged")["team"]; got != "payments" {
+		t.Errorf("renamed Meta() team = %v, want payments", got)
+	}
+	if got := sm.Meta("paid")["team"]; got != "payments" {
+		t.Errorf("original Meta() team = %v, want payments", got)
+	}
+}
@@ -0,0 +1,27 @@
+package gofsm
+
+import "context"
+
+type TriggerFunc func(ctx context.Context, from State, event Event) (State, error)
+
+/**
+Trigger 中间件，用于鉴权、链路追踪、限流等
+*/
+type Middleware func(next TriggerFunc) TriggerFunc
+
+/**
+注册中间件，先注册的中间件在最外层
+*/
+func (sm *StateMachine) Use(middlewares ...Middleware) *StateMachine {
+	sm.mutable()
+	sm.middlewares = append(sm.middlewares, middlewares...)
+	return sm
+}
+
+func (sm *StateMachine) chain(core TriggerFunc) TriggerFunc {
+	next := core
+	for i := len(sm.middlewares) - 1; i >= 0; i-- {
+		next = sm.middlewares[i](next)
+	}
+	return next
+}
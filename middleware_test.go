@@ -0,0 +1,58 @@
+package gofsm_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestStateMachine_Use(t *testing.T) {
+	var calls []string
+	trace := func(name string) gofsm.Middleware {
+		return func(next gofsm.TriggerFunc) gofsm.TriggerFunc {
+			return func(ctx context.Context, from gofsm.State, event gofsm.Event) (gofsm.State, error) {
+				calls = append(calls, name+" before")
+				to, err := next(ctx, from, event)
+				calls = append(calls, name+" after "+string(to))
+				return to, err
+			}
+		}
+	}
+	deny := func(next gofsm.TriggerFunc) gofsm.TriggerFunc {
+		return func(ctx context.Context, from gofsm.State, event gofsm.Event) (gofsm.State, error) {
+			if ctx.Value("user") == nil {
+				return from, errors.New("permission denied")
+			}
+			return next(ctx, from, event)
+		}
+	}
+
+	t.Run("Order", func(t *testing.T) {
+		calls = nil
+		sm := newOrderMachine().Use(trace("outer"), trace("inner"))
+		got, err := sm.Trigger(context.TODO(), "new", "pay")
+		if err != nil || got != "paid" {
+			t.Fatalf("StateMachine.Trigger() = %v, %v, want paid", got, err)
+		}
+		want := []string{"outer before", "inner before", "inner after paid", "outer after paid"}
+		if !reflect.DeepEqual(calls, want) {
+			t.Errorf("middleware calls = %v, want %v", calls, want)
+		}
+	})
+
+	t.Run("Short Circuit", func(t *testing.T) {
+		processor := &enterProcessor{}
+		sm := newOrderMachine().Processor(processor).Use(deny)
+		got, err := sm.TriggerX(context.TODO(), "new", "pay")
+		if err == nil || got.State != "new" || got.Transition.From != "" || processor.entered != nil {
+			t.Errorf("StateMachine.TriggerX() = %+v, %v, entered %v", got, err, processor.entered)
+		}
+		got, err = sm.TriggerX(context.WithValue(context.TODO(), "user", "admin"), "new", "pay")
+		if err != nil || got.State != "paid" || got.Transition.Event != "pay" {
+			t.Errorf("StateMachine.TriggerX() = %+v, %v, want paid", got, err)
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package gofsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+/**
+同时处于多个状态的实例，用于模拟 NFA
+Fire 在每个当前状态上触发事件，NFA 转换的每个目标状态都执行一次 Action，结果合并为新的状态集合
+*/
+type MultiInstance struct {
+	mu      sync.Mutex
+	sm      *StateMachine
+	current []State
+}
+
+/**
+创建一个处于 current 状态集合的实例，创建实例后状态机不能再修改
+*/
+func (sm *StateMachine) NewMultiInstance(current ...State) *MultiInstance {
+	atomic.StoreInt32(&sm.inUse, 1)
+	return &MultiInstance{sm: sm, current: stateSet(sm.normStateList(current))}
+}
+
+/**
+当前状态集合，按名称排序
+*/
+func (m *MultiInstance) Current() []State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]State(nil), m.current...)
+}
+
+/**
+在所有当前状态上触发事件，返回新的状态集合
+	- 没有该事件转换的状态从集合中去掉，所有状态都没有转换时返回错误，集合不变
+	- Action 返回 None 时保持原状态，Action 执行失败的分支去掉（进入错误状态时保留错误状态）
+	- 所有分支都失败时返回第一个错误，集合不变；部分分支失败时返回新的集合和第一个错误
+*/
+func (m *MultiInstance) Fire(ctx context.Context, event Event) ([]State, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var next []State
+	var firstErr error
+	for _, from := range m.current {
+		states, err := m.sm.fireAll(ctx, from, event)
+		next = append(next, states...)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if len(next) == 0 {
+		if firstErr == nil {
+			firstErr = fmt.Errorf("%w [%v --%v--> ???]", ErrNoTransition, m.current, event)
+		}
+		return append([]State(nil), m.current...), firstErr
+	}
+	m.current = stateSet(next)
+	return append([]State(nil), m.current...), firstErr
+}
+
+/**
+在 from 上触发事件，所有满足条件的转换的每个目标状态都执行一次，返回所有结果状态
+没有转换时返回 nil 和 nil
+*/
+func (sm *StateMachine) fireAll(ctx context.Context, from State, event Event) ([]State, error) {
+	from, event = sm.normState(from), sm.normEvent(event)
+	if primary, ok := sm.sg.aliases[event]; ok {
+		event = primary
+	}
+	if !sm.sg.contains(from) && !sm.permissive {
+		return nil, fmt.Errorf("%w%s", ErrUnknownState, from)
+	}
+	if _, ok := sm.sg.events[event]; !ok && event != None && !sm.permissive {
+		return nil, fmt.Errorf("%w %s", ErrUnknownEvent, event)
+	}
+	if len(sm.sg.transitions[from][event]) == 0 {
+		return nil, nil
+	}
+	transfers, _, err := sm.match(ctx, from, event, nil)
+	if err != nil {
+		if errors.Is(err, ErrGuardRejected) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var states []State
+	var firstErr error
+	for _, transfer := range transfers {
+		for _, target := range transfer.To {
+			result, err := sm.triggerX(ctx, from, event, triggerOptions{transfer: transfer, target: target})
+			to := result.State
+			if err != nil && !result.Recovered {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			if to == None {
+				to = from
+			}
+			states = append(states, to)
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return states, firstErr
+}
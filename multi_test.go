@@ -0,0 +1,56 @@
+package gofsm_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestMultiInstance_Fire(t *testing.T) {
+	failure := func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (gofsm.State, error) {
+		return gofsm.None, errors.New("failure")
+	}
+	newMachine := func() *gofsm.StateMachine {
+		return gofsm.New("review").
+			States(gofsm.StatesDef{"draft": "", "r1": "", "r2": "", "r3": "", "approved": "", "rejected": ""}).
+			Events(gofsm.EventsDef{"submit": "", "approve": "", "reject": ""}).
+			Transitions(
+				gofsm.Transition{From: "draft", Event: "submit", To: []gofsm.State{"r1", "r2", "r3"}, Action: gofsm.NoopAction},
+				gofsm.Transition{From: "r1", Event: "approve", To: []gofsm.State{"approved"}, Action: gofsm.NoopAction},
+				gofsm.Transition{From: "r2", Event: "approve", To: []gofsm.State{"approved"}, Action: gofsm.NoopAction},
+				gofsm.Transition{From: "r3", Event: "approve", To: []gofsm.State{"approved"}, Action: failure},
+				gofsm.Transition{From: "r3", Event: "reject", To: []gofsm.State{"rejected"}, Action: gofsm.NoopAction},
+			)
+	}
+
+	tests := []struct {
+		name    string
+		events  []gofsm.Event
+		want    []gofsm.State
+		wantErr bool
+	}{
+		{"Fork", []gofsm.Event{"submit"}, []gofsm.State{"r1", "r2", "r3"}, false},
+		{"Drop Without Transition", []gofsm.Event{"submit", "reject"}, []gofsm.State{"rejected"}, false},
+		{"Partial Failure", []gofsm.Event{"submit", "approve"}, []gofsm.State{"approved"}, true},
+		{"No Transition", []gofsm.Event{"approve"}, []gofsm.State{"draft"}, true},
+		{"Unknown Event", []gofsm.Event{"publish"}, []gofsm.State{"draft"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMachine().NewMultiInstance("draft")
+			var err error
+			for _, event := range tt.events {
+				_, err = m.Fire(context.TODO(), event)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MultiInstance.Fire() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := m.Current(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MultiInstance.Current() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package gofsm
+
+import "context"
+
+/**
+可以报告执行进度的 Action，通过 emit 报告进度
+*/
+type ProgressAction func(ctx context.Context, from State, event Event, to []State, emit func(progress interface{})) (State, error)
+
+/**
+执行转换的 Action，ArgAction 优先，然后是 Progress，没有 emit 时进度被丢弃
+*/
+func (transfer *Transition) run(ctx context.Context, from State, event Event, to []State, opts triggerOptions) (State, error) {
+	if transfer.ArgAction != nil {
+		return transfer.ArgAction(ctx, from, event, opts.arg, to)
+	}
+	emit := opts.emit
+	if transfer.Progress == nil {
+		return transfer.Action(ctx, from, event, to)
+	}
+	if emit == nil {
+		emit = func(interface{}) {}
+	}
+	return transfer.Progress(ctx, from, event, to, emit)
+}
+
+/**
+异步触发状态转换，返回进度通道和等待结果的函数
+	- 进度通道在转换完成后关闭，调用者需要读取进度直到通道关闭，否则 Action 在 emit 时阻塞直到 ctx 结束
+	- wait 等待转换完成并返回 Trigger 的结果，可以多次调用
+	- 普通的 Action 不报告进度，进度通道直接关闭
+*/
+func (sm *StateMachine) TriggerStream(ctx context.Context, from State, event Event) (<-chan interface{}, func() (State, error)) {
+	progress := make(chan interface{})
+	done := make(chan struct{})
+	var result TriggerResult
+	var err error
+
+	emit := func(p interface{}) {
+		select {
+		case progress <- p:
+		case <-ctx.Done():
+		}
+	}
+	go func() {
+		defer close(done)
+		defer close(progress)
+		result, err = sm.triggerX(ctx, from, event, triggerOptions{emit: emit})
+	}()
+
+	return progress, func() (State, error) {
+		<-done
+		return result.State, err
+	}
+}
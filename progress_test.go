@@ -0,0 +1,55 @@
+package gofsm_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestStateMachine_TriggerStream(t *testing.T) {
+	packing := func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State, emit func(progress interface{})) (gofsm.State, error) {
+		for i := 1; i <= 3; i++ {
+			emit(fmt.Sprintf("packing %d/3", i))
+		}
+		return to[0], nil
+	}
+	sm := newOrderMachine().
+		States(gofsm.StatesDef{"new": "", "paid": "", "packed": "", "sent": ""}).
+		Events(gofsm.EventsDef{"pay": "", "send": "", "pack": ""}).
+		Transitions(gofsm.Transition{From: "paid", Event: "pack", To: []gofsm.State{"packed"}, Progress: packing})
+
+	tests := []struct {
+		name  string
+		from  gofsm.State
+		event gofsm.Event
+		want  gofsm.State
+		steps []interface{}
+	}{
+		{"Progress Action", "paid", "pack", "packed", []interface{}{"packing 1/3", "packing 2/3", "packing 3/3"}},
+		{"Plain Action", "new", "pay", "paid", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progress, wait := sm.TriggerStream(context.TODO(), tt.from, tt.event)
+			var steps []interface{}
+			for p := range progress {
+				steps = append(steps, p)
+			}
+			if got, err := wait(); err != nil || got != tt.want {
+				t.Errorf("wait() = %v, %v, want %v", got, err, tt.want)
+			}
+			if !reflect.DeepEqual(steps, tt.steps) {
+				t.Errorf("progress = %v, want %v", steps, tt.steps)
+			}
+		})
+	}
+
+	t.Run("Trigger Ignores Progress", func(t *testing.T) {
+		if got, err := sm.Trigger(context.TODO(), "paid", "pack"); err != nil || got != "packed" {
+			t.Errorf("StateMachine.Trigger() = %v, %v, want packed", got, err)
+		}
+	})
+}
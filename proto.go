@@ -0,0 +1,130 @@
+package gofsm
+
+import "github.com/threeq/gofsm/fsmpb"
+
+/**
+导出为 protobuf 消息，内容与 Spec 一致，用于通过 gRPC 在服务之间传递状态机定义
+*/
+func (sm *StateMachine) ToProto() *fsmpb.StateMachine {
+	spec := sm.Spec()
+	m := &fsmpb.StateMachine{
+		Name:       spec.Name,
+		States:     map[string]string{},
+		Events:     map[string]string{},
+		Start:      stateStrings(spec.Start),
+		End:        stateStrings(spec.End),
+		ErrorState: string(spec.ErrorState),
+	}
+	for state, desc := range spec.States {
+		m.States[string(state)] = desc
+	}
+	for event, desc := range spec.Events {
+		m.Events[string(event)] = desc
+	}
+	if len(spec.Aliases) > 0 {
+		m.Aliases = map[string]string{}
+		for alias, primary := range spec.Aliases {
+			m.Aliases[string(alias)] = string(primary)
+		}
+	}
+	for _, t := range spec.Transitions {
+		m.Transitions = append(m.Transitions, &fsmpb.Transition{
+			From:     string(t.From),
+			Event:    string(t.Event),
+			To:       stateStrings(t.To),
+			Priority: int64(t.Priority),
+			Weights:  t.Weights,
+			Guarded:  t.Guarded,
+			Tags:     t.Tags,
+			Desc:     t.Desc,
+		})
+	}
+	return m
+}
+
+/**
+从 protobuf 消息加载状态机，只包含拓扑定义
+加载后执行 Validate，所有转换使用 NoopAction，Guarded 的转换不设置 Guard
+*/
+func FromProto(m *fsmpb.StateMachine) (*StateMachine, error) {
+	spec := &Spec{
+		Name:       m.Name,
+		States:     StatesDef{},
+		Events:     EventsDef{},
+		ErrorState: State(m.ErrorState),
+	}
+	for state, desc := range m.States {
+		spec.States[State(state)] = desc
+	}
+	for event, desc := range m.Events {
+		spec.Events[Event(event)] = desc
+	}
+	for alias, primary := range m.Aliases {
+		if spec.Aliases == nil {
+			spec.Aliases = map[Event]Event{}
+		}
+		spec.Aliases[Event(alias)] = Event(primary)
+	}
+	for _, state := range m.Start {
+		spec.Start = append(spec.Start, State(state))
+	}
+	for _, state := range m.End {
+		spec.End = append(spec.End, State(state))
+	}
+	for _, t := range m.Transitions {
+		transition := SpecTransition{
+			From:     State(t.From),
+			Event:    Event(t.Event),
+			Priority: int(t.Priority),
+			Weights:  t.Weights,
+			Guarded:  t.Guarded,
+			Tags:     t.Tags,
+			Desc:     t.Desc,
+		}
+		for _, to := range t.To {
+			transition.To = append(transition.To, State(to))
+		}
+		spec.Transitions = append(spec.Transitions, transition)
+	}
+	return fromSpec(spec)
+}
+
+/**
+按拓扑定义创建状态机，所有转换使用 NoopAction，创建后执行 Validate
+*/
+func fromSpec(spec *Spec) (*StateMachine, error) {
+	sm, err := define(func() *StateMachine {
+		return New(spec.Name).
+			States(spec.States).
+			Events(spec.Events).
+			Start(spec.Start).
+			End(spec.End).
+			ErrorState(spec.ErrorState)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for alias, primary := range spec.Aliases {
+		sm.AliasEvent(primary, alias)
+	}
+	transitions := make([]Transition, 0, len(spec.Transitions))
+	for _, t := range spec.Transitions {
+		transitions = append(transitions, Transition{
+			From:     t.From,
+			Event:    t.Event,
+			To:       append([]State{}, t.To...),
+			Action:   NoopAction,
+			Priority: t.Priority,
+			Weights:  append([]float64(nil), t.Weights...),
+			Tags:     append([]string(nil), t.Tags...),
+			Desc:     t.Desc,
+		})
+	}
+	if _, err := define(func() *StateMachine { return sm.Transitions(transitions...) }); err != nil {
+		return nil, err
+	}
+	if err := sm.Validate(); err != nil {
+		return nil, err
+	}
+	return sm, nil
+}
@@ -0,0 +1,70 @@
+package gofsm_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/threeq/gofsm"
+	"github.com/threeq/gofsm/fsmpb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestStateMachine_ToProto(t *testing.T) {
+	tests := []struct {
+		name string
+		sm   *gofsm.StateMachine
+	}{
+		{"Order", newOrderMachine().ErrorState("imported").AliasEvent("pay", "charge")},
+		{"Weighted", gofsm.New("weighted").
+			States(gofsm.StatesDef{"a": "开始", "b": "", "c": ""}).
+			Events(gofsm.EventsDef{"go": "前进"}).
+			Transitions(
+				gofsm.Transition{From: "a", Event: "go", To: []gofsm.State{"b", "c"}, Weights: []float64{0.25, 0.75}, Action: gofsm.NoopAction, Tags: []string{"auto"}, Desc: "随机", Priority: -1},
+				gofsm.Transition{From: "b", Event: "go", To: []gofsm.State{"c"}, Action: gofsm.NoopAction},
+			)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := proto.Marshal(tt.sm.ToProto())
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			m := &fsmpb.StateMachine{}
+			if err := proto.Unmarshal(data, m); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			loaded, err := gofsm.FromProto(m)
+			if err != nil {
+				t.Fatalf("FromProto() error = %v", err)
+			}
+			want, _ := json.Marshal(tt.sm.Spec())
+			got, _ := json.Marshal(loaded.Spec())
+			if string(got) != string(want) {
+				t.Errorf("FromProto().Spec() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestFromProto_Invalid(t *testing.T) {
+	m := &fsmpb.StateMachine{
+		States:      map[string]string{"a": ""},
+		Transitions: []*fsmpb.Transition{{From: "a", Event: "e", To: []string{"b"}}},
+	}
+	if _, err := gofsm.FromProto(m); err == nil {
+		t.Errorf("FromProto() want validation error")
+	}
+
+	m = &fsmpb.StateMachine{States: map[string]string{"a": "", "[*]": ""}}
+	if _, err := gofsm.FromProto(m); !errors.Is(err, gofsm.ErrReservedState) {
+		t.Errorf("FromProto() error = %v, want %v", err, gofsm.ErrReservedState)
+	}
+	m = &fsmpb.StateMachine{
+		States:      map[string]string{"a": ""},
+		Transitions: []*fsmpb.Transition{{From: "a", Event: "e", To: []string{gofsm.Start}}},
+	}
+	if _, err := gofsm.FromProto(m); !errors.Is(err, gofsm.ErrReservedState) {
+		t.Errorf("FromProto() error = %v, want %v", err, gofsm.ErrReservedState)
+	}
+}
@@ -0,0 +1,199 @@
+package gofsm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"qiniupkg.com/x/errors.v7"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	umlNamePattern       = regexp.MustCompile(`<b>\[(.*?)\]</b>`)
+	umlStatePattern      = regexp.MustCompile(`^state\s+"([^"]*)"\s+as\s+(\S+)(?:\s+<<\w+>>)?(?:\s+(#\w+))?\s*(?::(.*))?$`)
+	umlSimpleState       = regexp.MustCompile(`^state\s+([^\s"{:]+)\s*(?::(.*))?$`)
+	umlTransitionPattern = regexp.MustCompile(`^(\S+)\s+-+(?:\w+-+|\[([^\]]*)\]-+)?>\s*(\S+)\s*(?::(.*))?$`)
+	umlProbability       = regexp.MustCompile(`\s*p=([0-9.]+)$`)
+	umlAfterPattern      = regexp.MustCompile(`^after\((.+)\)$`)
+	umlTags              = strings.NewReplacer("<font color=red>", "", "</font>", "", "<b>", "", "</b>", "")
+)
+
+/**
+从 PlantUML 状态图加载状态机，支持 Show 输出的格式和手写的简单格式
+	- state "名称" as id <<NFA>> #颜色 :描述、state id : 描述 定义状态，颜色保存为 MetaColor 元数据
+	- A --> B : (事件 | 别名) 事件描述 p=0.50 定义转换，也可以写成 A --> B : 事件
+	- [*] --> A 定义开始状态，A --> [*] 定义结束状态，带有事件时是 From 为 Start、To 为 End 的转换
+	- after(时长) 事件定义 After 定时转换
+	- A -[dashed]-> B 定义被 Disable 禁用的转换
+	- 相同 from、event 的多条转换合并为 NFA 转换，带有 p= 时作为权重
+其他内容被忽略，加载后执行 Validate，所有转换使用 NoopAction
+*/
+func LoadPlantUML(r io.Reader) (*StateMachine, error) {
+	type edgeKey struct {
+		from  State
+		event Event
+	}
+
+	name := ""
+	states := StatesDef{}
+	events := EventsDef{}
+	aliases := map[Event][]Event{}
+	var start, end []State
+	var keys []edgeKey
+	edges := map[edgeKey]*Transition{}
+	type after struct {
+		from State
+		d    time.Duration
+		to   State
+	}
+	var afters []after
+	var disabled []edgeKey
+	colors := map[State]string{}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if name == "" {
+			if m := umlNamePattern.FindStringSubmatch(text); m != nil {
+				name = m[1]
+			}
+		}
+		if m := umlStatePattern.FindStringSubmatch(text); m != nil {
+			states[State(m[2])] = strings.TrimSpace(m[4])
+			if m[3] != "" {
+				colors[State(m[2])] = m[3]
+			}
+			continue
+		}
+		if m := umlSimpleState.FindStringSubmatch(text); m != nil {
+			states[State(m[1])] = strings.TrimSpace(m[2])
+			continue
+		}
+		m := umlTransitionPattern.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		from, to := State(m[1]), State(m[3])
+		if from == pseudoState && to == pseudoState {
+			return nil, errors.New(fmt.Sprintf("PlantUML 第 %d 行转换错误: %s", line, text))
+		}
+		label := strings.TrimSpace(umlTags.Replace(m[4]))
+		switch {
+		case from == pseudoState && label == "":
+			states[to] += ""
+			start = append(start, to)
+			continue
+		case to == pseudoState && label == "":
+			states[from] += ""
+			end = append(end, from)
+			continue
+		case from == pseudoState:
+			from = Start
+			states[to] += ""
+		case to == pseudoState:
+			to = End
+			states[from] += ""
+		default:
+			states[from] += ""
+			states[to] += ""
+		}
+
+		weight := -1.0
+		if p := umlProbability.FindStringSubmatch(label); p != nil {
+			if v, err := strconv.ParseFloat(p[1], 64); err == nil {
+				weight = v
+				label = strings.TrimSpace(strings.TrimSuffix(label, p[0]))
+			}
+		}
+		event, desc, alias := parseUMLLabel(label)
+		if p := umlAfterPattern.FindStringSubmatch(string(event)); p != nil && desc == "" {
+			if d, err := time.ParseDuration(p[1]); err == nil {
+				afters = append(afters, after{from: from, d: d, to: to})
+				continue
+			}
+		}
+		if event != None {
+			if events[event] == "" {
+				events[event] = desc
+			}
+			if len(alias) > 0 {
+				aliases[event] = alias
+			}
+		}
+
+		key := edgeKey{from: from, event: event}
+		transfer, ok := edges[key]
+		if !ok {
+			transfer = &Transition{From: from, Event: event, Action: NoopAction}
+			edges[key] = transfer
+			keys = append(keys, key)
+		}
+		if strings.Contains(m[2], "dashed") {
+			disabled = append(disabled, key)
+		}
+		transfer.To = append(transfer.To, to)
+		if weight >= 0 {
+			for len(transfer.Weights) < len(transfer.To)-1 {
+				transfer.Weights = append(transfer.Weights, 1)
+			}
+			transfer.Weights = append(transfer.Weights, weight)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.New(fmt.Sprintf("读取 PlantUML 失败: %v", err))
+	}
+
+	var transitions []Transition
+	for _, key := range keys {
+		transitions = append(transitions, *edges[key])
+	}
+	sm, err := define(func() *StateMachine {
+		return New(name).
+			States(states).
+			Events(events).
+			Start(removeRepByMap(start)).
+			End(removeRepByMap(end)).
+			Transitions(transitions...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for primary, alias := range aliases {
+		sm.AliasEvent(primary, alias...)
+	}
+	for _, a := range afters {
+		sm.After(a.from, a.d, a.to, nil)
+	}
+	for state, color := range colors {
+		sm.StateMeta(state, map[string]string{MetaColor: color})
+	}
+	for _, key := range disabled {
+		sm.Disable(key.from, key.event)
+	}
+	if err := sm.Validate(); err != nil {
+		return nil, err
+	}
+	return sm, nil
+}
+
+/**
+解析转换标签 "(事件 | 别名) 描述"，没有括号时整个标签是事件
+*/
+func parseUMLLabel(label string) (Event, string, []Event) {
+	if !strings.HasPrefix(label, "(") {
+		return Event(label), "", nil
+	}
+	end := strings.Index(label, ")")
+	if end < 0 {
+		return Event(label), "", nil
+	}
+	names := strings.Split(label[1:end], "|")
+	var aliases []Event
+	for _, alias := range names[1:] {
+		aliases = append(aliases, Event(strings.TrimSpace(alias)))
+	}
+	return Event(strings.TrimSpace(names[0])), strings.TrimSpace(label[end+1:]), aliases
+}
@@ -0,0 +1,166 @@
+package gofsm_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestLoadPlantUML_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		sm   *gofsm.StateMachine
+	}{
+		{"Order", newOrderMachine()},
+		{"Alias", newOrderMachine().AliasEvent("pay", "checkout", "purchase")},
+		{"NFA", newOrderMachine().Transitions(
+			gofsm.Transition{From: "paid", Event: "send", To: []gofsm.State{"imported"}, Action: gofsm.NoopAction},
+		)},
+		{"Weighted", newOrderMachine().Transitions(
+			gofsm.Transition{From: "new", Event: "send", To: []gofsm.State{"sent", "imported"}, Weights: []float64{1, 3}, Action: gofsm.NoopAction},
+		)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gofsm.LoadPlantUML(strings.NewReader(plantUMLScript(t, tt.sm)))
+			if err != nil {
+				t.Fatalf("LoadPlantUML() error = %v", err)
+			}
+			want := tt.sm.Spec()
+			if tt.name == "Weighted" {
+				for i := range want.Transitions {
+					if want.Transitions[i].Weights != nil {
+						want.Transitions[i].Weights = []float64{0.25, 0.75}
+					}
+				}
+			}
+			if spec := got.Spec(); !reflect.DeepEqual(spec, want) {
+				t.Errorf("LoadPlantUML().Spec() = %+v, want %+v", spec, want)
+			}
+		})
+	}
+}
+
+func TestLoadPlantUML_PseudoStateTransitions(t *testing.T) {
+	sm := newOrderMachine().
+		Events(gofsm.EventsDef{"create": "创建", "pay": "支付", "send": "发货", "archive": ""}).
+		Transitions(
+			gofsm.Transition{From: gofsm.Start, Event: "create", To: []gofsm.State{"new"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "sent", Event: "archive", To: []gofsm.State{gofsm.End}, Action: gofsm.NoopAction},
+		)
+	got, err := gofsm.LoadPlantUML(strings.NewReader(plantUMLScript(t, sm)))
+	if err != nil {
+		t.Fatalf("LoadPlantUML() error = %v", err)
+	}
+	if got.Fingerprint() != sm.Fingerprint() {
+		t.Errorf("LoadPlantUML().Spec() = %+v, want %+v", got.Spec(), sm.Spec())
+	}
+}
+
+func TestLoadPlantUML_Color(t *testing.T) {
+	sm := newOrderMachine().
+		States(gofsm.StatesDef{"new": "新建", "paid": "已支付", "sent": "已发货", "imported": "导入", "lost": "丢失"}).
+		StateMeta("paid", map[string]string{gofsm.MetaColor: "#ff0000"}).
+		StateMeta("lost", map[string]string{gofsm.MetaColor: "LightBlue"})
+	var script bytes.Buffer
+	if _, err := sm.WriteFormat(&script, gofsm.FormatPlantUML); err != nil {
+		t.Fatalf("StateMachine.WriteFormat() error = %v", err)
+	}
+	got, err := gofsm.LoadPlantUML(&script)
+	if err != nil {
+		t.Fatalf("LoadPlantUML() error = %v", err)
+	}
+	if spec, want := got.Spec(), sm.Spec(); !reflect.DeepEqual(spec.States, want.States) {
+		t.Errorf("LoadPlantUML().Spec().States = %v, want %v", spec.States, want.States)
+	}
+	for state, want := range map[gofsm.State]string{"paid": "#ff0000", "lost": "#LightBlue", "new": ""} {
+		if color := got.Meta(state)[gofsm.MetaColor]; color != want {
+			t.Errorf("LoadPlantUML().Meta(%s) color = %q, want %q", state, color, want)
+		}
+	}
+}
+
+func TestLoadPlantUML_Disabled(t *testing.T) {
+	sm := newOrderMachine()
+	sm.Disable("paid", "send")
+	var script bytes.Buffer
+	if _, err := sm.WriteFormat(&script, gofsm.FormatPlantUML); err != nil {
+		t.Fatalf("StateMachine.WriteFormat() error = %v", err)
+	}
+	got, err := gofsm.LoadPlantUML(&script)
+	if err != nil {
+		t.Fatalf("LoadPlantUML() error = %v", err)
+	}
+	if spec, want := got.Spec(), sm.Spec(); !reflect.DeepEqual(spec, want) {
+		t.Errorf("LoadPlantUML().Spec() = %+v, want %+v", spec, want)
+	}
+	if _, err := got.Trigger(context.TODO(), "paid", "send"); !errors.Is(err, gofsm.ErrNoTransition) {
+		t.Errorf("LoadPlantUML().Trigger() error = %v, want %v", err, gofsm.ErrNoTransition)
+	}
+	if state, err := got.Trigger(context.TODO(), "new", "pay"); err != nil || state != "paid" {
+		t.Errorf("LoadPlantUML().Trigger() = %v, %v, want paid", state, err)
+	}
+}
+
+func TestLoadPlantUML(t *testing.T) {
+	tests := []struct {
+		name    string
+		puml    string
+		want    []string
+		wantErr bool
+	}{
+		{"Hand Written", `@startuml
+state Idle : 空闲
+[*] --> Idle
+Idle -> Running : start
+Running --> Idle : stop
+Running --> [*]
+@enduml`, []string{"Idle --(start)--> Running", "Running --(stop)--> Idle"}, false},
+		{"Start To End", "[*] --> [*]", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm, err := gofsm.LoadPlantUML(strings.NewReader(tt.puml))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadPlantUML() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			var got []string
+			sm.EachTransition(func(from gofsm.State, event gofsm.Event, to gofsm.State) bool {
+				got = append(got, string(from)+" --("+string(event)+")--> "+string(to))
+				return true
+			})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("transitions = %v, want %v", got, tt.want)
+			}
+			spec := sm.Spec()
+			if spec.States["Idle"] != "空闲" || !reflect.DeepEqual(spec.Start, []gofsm.State{"Idle"}) || !reflect.DeepEqual(spec.End, []gofsm.State{"Running"}) {
+				t.Errorf("LoadPlantUML().Spec() = %+v", spec)
+			}
+		})
+	}
+}
+
+func TestLoadPlantUML_After(t *testing.T) {
+	sm := newOrderMachine().
+		States(gofsm.StatesDef{"new": "", "paid": "", "sent": "", "imported": "", "cancelled": ""}).
+		After("new", 30*time.Minute, "cancelled", nil)
+	got, err := gofsm.LoadPlantUML(strings.NewReader(plantUMLScript(t, sm)))
+	if err != nil {
+		t.Fatalf("LoadPlantUML() error = %v", err)
+	}
+	if show := plantUMLScript(t, got); !strings.Contains(show, "new --> cancelled : after(30m0s)") {
+		t.Errorf("LoadPlantUML() script = %v, want after transition", show)
+	}
+	if _, ok := got.Spec().Events["after(30m0s)"]; ok {
+		t.Errorf("LoadPlantUML() after transition loaded as event")
+	}
+}
@@ -0,0 +1,79 @@
+package gofsm
+
+import "context"
+
+/**
+事件队列的缓冲大小，队列满时 Post 阻塞
+*/
+const queueSize = 64
+
+/**
+Run 处理完一个事件后的回调，参数与 Fire 的结果一致
+*/
+type Observer func(event Event, state State, err error)
+
+/**
+设置 Run 处理事件结果的回调
+*/
+func (i *Instance) Observe(observer Observer) *Instance {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.observer = observer
+	return i
+}
+
+/**
+把事件放入实例的事件队列，由 Run 按顺序处理
+*/
+func (i *Instance) Post(event Event) {
+	i.queue <- event
+}
+
+/**
+按顺序处理事件队列中的事件，每个事件通过 Fire 处理，结果交给 Observe 设置的回调
+当前状态定义了 After 定时转换时开始计时，超时前没有发生状态变化则执行定时转换
+当前状态定义了 StateSLA 时同样开始计时，超时后调用告警回调，不改变状态
+ctx 结束时返回，队列中未处理的事件保留，可以再次 Run 处理
+*/
+func (i *Instance) Run(ctx context.Context) {
+	timer, alarm := i.armTimer(nil), i.armSLA(nil)
+	defer func() {
+		timer.stop()
+		alarm.stop()
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-i.queue:
+			state, err := i.Fire(ctx, event)
+			i.notify(event, state, err)
+			if err == nil || state != timer.state {
+				timer = i.armTimer(timer)
+			}
+			if err == nil || state != alarm.state {
+				alarm = i.armSLA(alarm)
+			}
+		case <-timer.C:
+			event, state, ok, err := i.fireTimeout(ctx, timer.state)
+			if ok {
+				i.notify(event, state, err)
+			}
+			timer = i.armTimer(timer)
+			if ok || state != alarm.state {
+				alarm = i.armSLA(alarm)
+			}
+		case <-alarm.C:
+			i.breach(ctx, alarm)
+		}
+	}
+}
+
+func (i *Instance) notify(event Event, state State, err error) {
+	i.mu.Lock()
+	observer := i.observer
+	i.mu.Unlock()
+	if observer != nil {
+		observer(event, state, err)
+	}
+}
@@ -0,0 +1,48 @@
+package gofsm_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestInstance_Run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	results := make(chan string)
+	ins := newOrderMachine().NewInstance("new").
+		Observe(func(event gofsm.Event, state gofsm.State, err error) {
+			results <- fmt.Sprintf("%s:%s:%v", event, state, err != nil)
+		})
+	go ins.Run(ctx)
+
+	for _, event := range []gofsm.Event{"pay", "pay", "send"} {
+		ins.Post(event)
+	}
+	var got []string
+	for i := 0; i < 3; i++ {
+		got = append(got, <-results)
+	}
+	if want := []string{"pay:paid:false", "pay:paid:true", "send:sent:false"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("results = %v, want %v", got, want)
+	}
+	if ins.Current() != "sent" {
+		t.Errorf("Instance.Current() = %v, want sent", ins.Current())
+	}
+}
+
+func TestInstance_Run_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ins := newOrderMachine().NewInstance("new")
+	cancel()
+	ins.Run(ctx)
+
+	ins.Post("pay")
+	if ins.Current() != "new" {
+		t.Errorf("Instance.Current() = %v, want new", ins.Current())
+	}
+}
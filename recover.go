@@ -0,0 +1,50 @@
+package gofsm
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+/**
+Action、Guard、事件处理器发生 panic 时返回的错误，可以通过 errors.Is(err, ErrPanic) 判断
+*/
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrPanic, e.Value)
+}
+
+func (e *PanicError) Unwrap() error {
+	return ErrPanic
+}
+
+/**
+捕获 Action、Guard 和事件处理器中的 panic，转换为 *PanicError
+	- Guard、OnExit、Action 的 panic 通过 OnActionFailure 处理，然后与 Action 出错一样进入错误状态
+	- OnEnter 的 panic 直接返回
+	- OnActionFailure 的 panic 被忽略
+默认不捕获，panic 会传递给 Trigger 的调用者
+*/
+func (sm *StateMachine) RecoverPanics(recover bool) *StateMachine {
+	sm.mutable()
+	sm.recoverPanics = recover
+	return sm
+}
+
+/**
+执行用户提供的函数，开启 RecoverPanics 时把 panic 转换为错误
+*/
+func (sm *StateMachine) safely(fn func() error) (err error) {
+	if !sm.recoverPanics {
+		return fn()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
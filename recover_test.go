@@ -0,0 +1,119 @@
+package gofsm_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+type failureProcessor struct {
+	gofsm.DefaultProcessor
+	failures []error
+}
+
+func (p *failureProcessor) OnActionFailure(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State, err error) error {
+	p.failures = append(p.failures, err)
+	return nil
+}
+
+type panicProcessor struct {
+	gofsm.DefaultProcessor
+	onExit, onEnter bool
+}
+
+func (p *panicProcessor) OnExit(ctx context.Context, state gofsm.State, event gofsm.Event) error {
+	if p.onExit {
+		panic("exit")
+	}
+	return nil
+}
+
+func (p *panicProcessor) OnEnter(ctx context.Context, state gofsm.State) error {
+	if p.onEnter {
+		panic("enter")
+	}
+	return nil
+}
+
+func TestStateMachine_RecoverPanics(t *testing.T) {
+	panicAction := func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (gofsm.State, error) {
+		panic("action")
+	}
+	panicGuard := func(ctx context.Context, from gofsm.State, event gofsm.Event) (bool, error) {
+		panic("guard")
+	}
+	newMachine := func(transfer gofsm.Transition) (*gofsm.StateMachine, *failureProcessor) {
+		processor := &failureProcessor{}
+		transfer.From, transfer.Event, transfer.To = "a", "e1", []gofsm.State{"b"}
+		if transfer.Action == nil {
+			transfer.Action = gofsm.NoopAction
+		}
+		sm := gofsm.New("").
+			States(gofsm.StatesDef{"a": "", "b": "", "failed": ""}).
+			Events(gofsm.EventsDef{"e1": ""}).
+			Transitions(transfer).
+			Processor(processor).
+			RecoverPanics(true)
+		return sm, processor
+	}
+
+	tests := []struct {
+		name         string
+		transfer     gofsm.Transition
+		errorState   gofsm.State
+		want         gofsm.State
+		wantFailures int
+	}{
+		{"Action", gofsm.Transition{Action: panicAction}, gofsm.None, gofsm.None, 1},
+		{"Action Error State", gofsm.Transition{Action: panicAction}, "failed", "failed", 1},
+		{"Guard", gofsm.Transition{Guard: panicGuard}, gofsm.None, gofsm.None, 1},
+		{"Guard Error State", gofsm.Transition{Guard: panicGuard}, "failed", "failed", 1},
+		{"OnExit", gofsm.Transition{Processor: &panicProcessor{onExit: true}}, gofsm.None, gofsm.None, 0},
+		{"OnEnter", gofsm.Transition{Processor: &panicProcessor{onEnter: true}}, gofsm.None, "b", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm, processor := newMachine(tt.transfer)
+			if tt.errorState != gofsm.None {
+				sm.ErrorState(tt.errorState)
+			}
+			got, err := sm.Trigger(context.TODO(), "a", "e1")
+			var panicErr *gofsm.PanicError
+			if !errors.As(err, &panicErr) || !errors.Is(err, gofsm.ErrPanic) || len(panicErr.Stack) == 0 {
+				t.Fatalf("StateMachine.Trigger() error = %v, want *PanicError", err)
+			}
+			if got != tt.want {
+				t.Errorf("StateMachine.Trigger() = %v, want %v", got, tt.want)
+			}
+			if len(processor.failures) != tt.wantFailures {
+				t.Errorf("OnActionFailure calls = %v, want %v", len(processor.failures), tt.wantFailures)
+			}
+		})
+	}
+
+	t.Run("Guard Recovered", func(t *testing.T) {
+		sm, _ := newMachine(gofsm.Transition{Guard: panicGuard})
+		sm.ErrorState("failed")
+		result, err := sm.TriggerX(context.TODO(), "a", "e1")
+		if !errors.Is(err, gofsm.ErrPanic) || result.State != "failed" || !result.Recovered {
+			t.Errorf("StateMachine.TriggerX() = %+v, %v, want recovered to failed", result, err)
+		}
+		instance := sm.NewInstance("a")
+		if got, err := instance.Fire(context.TODO(), "e1"); !errors.Is(err, gofsm.ErrPanic) || got != "failed" || instance.Current() != "failed" {
+			t.Errorf("Instance.Fire() = %v, %v, current %v, want failed", got, err, instance.Current())
+		}
+	})
+
+	t.Run("Fail Fast", func(t *testing.T) {
+		sm, _ := newMachine(gofsm.Transition{Action: panicAction})
+		sm.RecoverPanics(false)
+		defer func() {
+			if recover() == nil {
+				t.Errorf("StateMachine.Trigger() want panic")
+			}
+		}()
+		_, _ = sm.Trigger(context.TODO(), "a", "e1")
+	})
+}
@@ -0,0 +1,96 @@
+package gofsm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/**
+状态机注册表，集中管理服务中的所有状态机，并发安全
+*/
+type Registry struct {
+	mu       sync.RWMutex
+	machines map[string]*StateMachine
+}
+
+/**
+创建空的注册表
+*/
+func NewRegistry() *Registry {
+	return &Registry{machines: map[string]*StateMachine{}}
+}
+
+/**
+注册状态机，名称重复时 panic
+*/
+func (r *Registry) Register(name string, sm *StateMachine) *Registry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.machines[name]; ok {
+		panic(fmt.Sprintf("状态机 %s 重复注册", name))
+	}
+	r.machines[name] = sm
+	return r
+}
+
+/**
+查找状态机，不存在时返回 nil, false
+*/
+func (r *Registry) Get(name string) (*StateMachine, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	sm, ok := r.machines[name]
+	return sm, ok
+}
+
+/**
+所有状态机的名称，按名称排序
+*/
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.machines))
+	for name := range r.machines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/**
+校验所有状态机，全部通过返回 nil，否则返回 ValidationErrors
+*/
+func (r *Registry) ValidateAll() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	errs := ValidationErrors{}
+	for name, sm := range r.machines {
+		if err := sm.Validate(); err != nil {
+			errs[name] = err
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+/**
+按注册名称汇总的校验错误
+*/
+type ValidationErrors map[string]error
+
+func (errs ValidationErrors) Error() string {
+	names := make([]string, 0, len(errs))
+	for name := range errs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	problems := make([]string, len(names))
+	for i, name := range names {
+		problems[i] = fmt.Sprintf("[%s] %v", name, errs[name])
+	}
+	return strings.Join(problems, "\n")
+}
@@ -0,0 +1,74 @@
+package gofsm_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestRegistry(t *testing.T) {
+	broken := gofsm.New("broken").
+		States(gofsm.StatesDef{"a": ""}).
+		Events(gofsm.EventsDef{"e": ""}).
+		Transitions(gofsm.Transition{From: "a", Event: "e", To: []gofsm.State{"b"}, Action: gofsm.NoopAction})
+
+	tests := []struct {
+		name     string
+		machines map[string]*gofsm.StateMachine
+		invalid  []string
+	}{
+		{"Empty", nil, nil},
+		{"All Valid", map[string]*gofsm.StateMachine{"order": newOrderMachine()}, nil},
+		{"Some Invalid", map[string]*gofsm.StateMachine{"order": newOrderMachine(), "z": broken, "a": broken}, []string{"a", "z"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gofsm.NewRegistry()
+			for name, sm := range tt.machines {
+				r.Register(name, sm)
+			}
+			err := r.ValidateAll()
+			if tt.invalid == nil {
+				if err != nil {
+					t.Errorf("Registry.ValidateAll() error = %v", err)
+				}
+				return
+			}
+			errs, ok := err.(gofsm.ValidationErrors)
+			if !ok {
+				t.Fatalf("Registry.ValidateAll() error = %T, want ValidationErrors", err)
+			}
+			var got []string
+			for _, name := range r.Names() {
+				if errs[name] != nil {
+					got = append(got, name)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.invalid) {
+				t.Errorf("Registry.ValidateAll() invalid = %v, want %v", got, tt.invalid)
+			}
+			if !strings.HasPrefix(err.Error(), "[a] ") {
+				t.Errorf("Registry.ValidateAll() error = %v, want sorted by name", err)
+			}
+		})
+	}
+}
+
+func TestRegistry_Get(t *testing.T) {
+	sm := newOrderMachine()
+	r := gofsm.NewRegistry().Register("order", sm)
+	if got, ok := r.Get("order"); !ok || got != sm {
+		t.Errorf("Registry.Get() = %v, %v, want registered machine", got, ok)
+	}
+	if _, ok := r.Get("missing"); ok {
+		t.Errorf("Registry.Get() missing want false")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Registry.Register() duplicate want panic")
+		}
+	}()
+	r.Register("order", sm)
+}
@@ -0,0 +1,118 @@
+package gofsm
+
+import (
+	"fmt"
+	"qiniupkg.com/x/errors.v7"
+)
+
+/**
+重命名状态，同时修改开始、结束、错误、死状态，所有转换的 From、To、禁用的转换、定时转换、停留时间告警、元数据以及不变式
+old 不存在或者 name 已经存在时返回错误，不做任何修改
+*/
+func (sm *StateMachine) RenameState(old, name State) error {
+	sm.mutable()
+	sg := sm.sg
+	if _, ok := sg.states[old]; !ok {
+		return errors.New(fmt.Sprintf("状态 %s 不存在", old))
+	}
+	if _, ok := sg.states[name]; ok {
+		return errors.New(fmt.Sprintf("状态 %s 已经存在", name))
+	}
+
+	rename := func(state State) State {
+		if state == old {
+			return name
+		}
+		return state
+	}
+	renameAll := func(states []State) {
+		for i, state := range states {
+			states[i] = rename(state)
+		}
+	}
+
+	sg.states[name] = sg.states[old]
+	delete(sg.states, old)
+	renameAll(sg.start)
+	renameAll(sg.end)
+	sg.errorState = rename(sg.errorState)
+	sg.deadState = rename(sg.deadState)
+	if events, ok := sg.transitions[old]; ok {
+		sg.transitions[name] = events
+		delete(sg.transitions, old)
+	}
+	for _, events := range sg.transitions {
+		for _, transfers := range events {
+			for _, transfer := range transfers {
+				transfer.From = rename(transfer.From)
+				renameAll(transfer.To)
+			}
+		}
+	}
+	sg.renameDisabled(func(key transitionKey) transitionKey {
+		return transitionKey{rename(key.from), key.event}
+	})
+	if t, ok := sg.timeouts[old]; ok {
+		sg.timeouts[name] = t
+		delete(sg.timeouts, old)
+	}
+	for _, t := range sg.timeouts {
+		t.transfer.From = rename(t.transfer.From)
+		renameAll(t.transfer.To)
+	}
+	if s, ok := sg.slas[old]; ok {
+		sg.slas[name] = s
+		delete(sg.slas, old)
+	}
+	if meta, ok := sg.meta[old]; ok {
+		sg.meta[name] = meta
+		delete(sg.meta, old)
+	}
+	if fns, ok := sm.invariants[old]; ok {
+		sm.invariants[name] = fns
+		delete(sm.invariants, old)
+	}
+	return nil
+}
+
+/**
+重命名事件，同时修改所有转换的 Event、禁用的转换和事件别名
+old 不存在或者 name 已经存在（包括作为别名）时返回错误，不做任何修改
+*/
+func (sm *StateMachine) RenameEvent(old, name Event) error {
+	sm.mutable()
+	sg := sm.sg
+	if _, ok := sg.events[old]; !ok {
+		return errors.New(fmt.Sprintf("事件 %s 不存在", old))
+	}
+	if _, ok := sg.events[name]; ok {
+		return errors.New(fmt.Sprintf("事件 %s 已经存在", name))
+	}
+	if _, ok := sg.aliases[name]; ok {
+		return errors.New(fmt.Sprintf("事件 %s 已经存在", name))
+	}
+
+	sg.events[name] = sg.events[old]
+	delete(sg.events, old)
+	for _, events := range sg.transitions {
+		if transfers, ok := events[old]; ok {
+			for _, transfer := range transfers {
+				transfer.Event = name
+			}
+			events[name] = transfers
+			delete(events, old)
+		}
+	}
+	sg.renameDisabled(func(key transitionKey) transitionKey {
+		if key.event == old {
+			key.event = name
+		}
+		return key
+	})
+	for alias, primary := range sg.aliases {
+		if primary == old {
+			sg.aliases[alias] = name
+		}
+	}
+	return nil
+}
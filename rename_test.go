@@ -0,0 +1,117 @@
+package gofsm_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestStateMachine_RenameState(t *testing.T) {
+	tests := []struct {
+		name    string
+		old     gofsm.State
+		new     gofsm.State
+		wantErr bool
+	}{
+		{"Rename", "paid", "payed", false},
+		{"Not Exists", "lost", "found", true},
+		{"Already Exists", "paid", "sent", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newOrderMachine().ErrorState("paid").
+				States(gofsm.StatesDef{"new": "", "paid": "已支付", "sent": "", "imported": ""}).
+				After("paid", time.Hour, "new", nil)
+			before := sm.Spec()
+			err := sm.RenameState(tt.old, tt.new)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("StateMachine.RenameState() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !reflect.DeepEqual(sm.Spec(), before) {
+					t.Errorf("StateMachine.RenameState() modified machine on error")
+				}
+				return
+			}
+			spec := sm.Spec()
+			if _, ok := spec.States["paid"]; ok || spec.States["payed"] != "已支付" || spec.ErrorState != "payed" {
+				t.Errorf("StateMachine.Spec() = %+v", spec)
+			}
+			if got, err := sm.Trigger(context.TODO(), "new", "pay"); err != nil || got != "payed" {
+				t.Errorf("StateMachine.Trigger() = %v, %v, want payed", got, err)
+			}
+			if got, err := sm.Trigger(context.TODO(), "payed", "send"); err != nil || got != "sent" {
+				t.Errorf("StateMachine.Trigger() = %v, %v, want sent", got, err)
+			}
+			if err := sm.Validate(); err != nil {
+				t.Errorf("StateMachine.Validate() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestStateMachine_Rename_Disabled(t *testing.T) {
+	tests := []struct {
+		name   string
+		rename func(sm *gofsm.StateMachine) error
+		from   gofsm.State
+		event  gofsm.Event
+	}{
+		{"State", func(sm *gofsm.StateMachine) error { return sm.RenameState("paid", "payed") }, "payed", "send"},
+		{"Event", func(sm *gofsm.StateMachine) error { return sm.RenameEvent("send", "ship") }, "paid", "ship"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newOrderMachine()
+			sm.Disable("paid", "send")
+			if err := tt.rename(sm); err != nil {
+				t.Fatalf("rename error = %v", err)
+			}
+			if _, err := sm.Trigger(context.TODO(), tt.from, tt.event); !errors.Is(err, gofsm.ErrNoTransition) {
+				t.Errorf("StateMachine.Trigger() error = %v, want %v", err, gofsm.ErrNoTransition)
+			}
+			sm.Enable(tt.from, tt.event)
+			if got, err := sm.Trigger(context.TODO(), tt.from, tt.event); err != nil || got != "sent" {
+				t.Errorf("StateMachine.Trigger() = %v, %v, want sent", got, err)
+			}
+		})
+	}
+}
+
+func TestStateMachine_RenameEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		old     gofsm.Event
+		new     gofsm.Event
+		wantErr bool
+	}{
+		{"Rename", "pay", "checkout", false},
+		{"Not Exists", "refund", "return", true},
+		{"Already Exists", "pay", "send", true},
+		{"Alias Exists", "pay", "purchase", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newOrderMachine().AliasEvent("pay", "purchase")
+			err := sm.RenameEvent(tt.old, tt.new)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("StateMachine.RenameEvent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			for _, event := range []gofsm.Event{"checkout", "purchase"} {
+				if got, err := sm.Trigger(context.TODO(), "new", event); err != nil || got != "paid" {
+					t.Errorf("StateMachine.Trigger(%s) = %v, %v, want paid", event, got, err)
+				}
+			}
+			if _, err := sm.Trigger(context.TODO(), "new", "pay"); err == nil {
+				t.Errorf("StateMachine.Trigger(pay) want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package gofsm
+
+import (
+	"context"
+	"fmt"
+)
+
+/**
+历史记录中按顺序触发的事件（包含进入错误状态的转换），Reset 之后重新开始
+保存事件列表后可以通过 Replay 在新的实例上重建状态
+*/
+func (i *Instance) Events() []Event {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	var events []Event
+	for _, r := range i.history {
+		switch r.Kind {
+		case RecordReset:
+			events = nil
+		case RecordTransition:
+			events = append(events, r.Event)
+		}
+	}
+	return events
+}
+
+/**
+在实例上按顺序重新触发 events，用于根据保存的事件列表重建状态（事件溯源式调试）
+	- 与 Fire 一样执行，出错后继续执行后面的事件，返回第一个错误
+	- 只有 Action、Guard、Resolver 和事件处理器都是纯函数时结果才是确定的：
+	  不能依赖时间、随机数（例如 WeightedResolver）或者外部服务的状态，也不能有副作用，否则重放的状态可能不同，副作用会再次执行
+通常在与原实例相同开始状态的新实例上调用
+*/
+func (i *Instance) Replay(ctx context.Context, events []Event) error {
+	var first error
+	for n, event := range events {
+		if _, err := i.Fire(ctx, event); err != nil && first == nil {
+			first = fmt.Errorf("重放第 %d 个事件 %s 失败: %w", n+1, event, err)
+		}
+	}
+	return first
+}
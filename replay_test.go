@@ -0,0 +1,56 @@
+package gofsm_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestInstance_Replay(t *testing.T) {
+	failure := func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (gofsm.State, error) {
+		return gofsm.None, errors.New("action error")
+	}
+	sm := newOrderMachine().
+		States(gofsm.StatesDef{"new": "", "paid": "", "sent": "", "error": ""}).
+		Events(gofsm.EventsDef{"pay": "", "send": "", "fail": ""}).
+		ErrorState("error").
+		Transitions(
+			gofsm.Transition{From: "paid", Event: "pay", To: []gofsm.State{"new"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "new", Event: "fail", To: []gofsm.State{"paid"}, Action: failure},
+		)
+
+	original := sm.NewInstance("new")
+	for _, event := range []gofsm.Event{"pay", "pay", "fail", "pay"} {
+		_, _ = original.Fire(context.TODO(), event)
+	}
+	events := original.Events()
+	if want := []gofsm.Event{"pay", "pay", "fail"}; !reflect.DeepEqual(events, want) {
+		t.Fatalf("Instance.Events() = %v, want %v", events, want)
+	}
+	history := original.History()
+	if last := history[len(history)-1]; last.To != "error" || last.Err == nil || last.Time.IsZero() {
+		t.Errorf("Instance.History() last = %+v, want error record with time", last)
+	}
+
+	replayed := sm.NewInstance("new")
+	err := replayed.Replay(context.TODO(), events)
+	if err == nil {
+		t.Errorf("Instance.Replay() want error from fail")
+	}
+	if got, want := replayed.Current(), original.Current(); got != want {
+		t.Errorf("Instance.Replay() state = %v, want %v", got, want)
+	}
+	if got, want := replayed.Events(), events; !reflect.DeepEqual(got, want) {
+		t.Errorf("Instance.Replay() events = %v, want %v", got, want)
+	}
+
+	if err := original.Reset(context.TODO()); err != nil {
+		t.Fatalf("Instance.Reset() error = %v", err)
+	}
+	if got := original.Events(); got != nil {
+		t.Errorf("Instance.Events() after Reset = %v, want nil", got)
+	}
+}
@@ -0,0 +1,106 @@
+package gofsm
+
+import (
+	"math/rand"
+	"sync"
+)
+
+/**
+NFA 目标状态选择器，从多个目标状态中选择一个
+weights 与 to 一一对应，没有设置权重的目标状态权重为 1
+*/
+type Resolver func(from State, event Event, to []State, weights []float64) State
+
+/**
+设置 NFA 目标状态选择器
+设置后，有多个目标状态的转换先由 Resolver 选择一个目标状态，Action 只收到被选中的状态
+*/
+func (sm *StateMachine) Resolver(resolver Resolver) *StateMachine {
+	sm.mutable()
+	sm.resolver = resolver
+	return sm
+}
+
+/**
+按权重随机选择目标状态，相同的 seed 产生相同的选择序列，用于模拟
+*/
+func WeightedResolver(seed int64) Resolver {
+	var mu sync.Mutex
+	random := rand.New(rand.NewSource(seed))
+	return func(from State, event Event, to []State, weights []float64) State {
+		if len(to) == 0 {
+			return None
+		}
+		total := 0.0
+		for i := range to {
+			total += weightAt(weights, i)
+		}
+		if total <= 0 {
+			return to[0]
+		}
+
+		mu.Lock()
+		r := random.Float64() * total
+		mu.Unlock()
+		for i := range to {
+			r -= weightAt(weights, i)
+			if r < 0 {
+				return to[i]
+			}
+		}
+		return to[len(to)-1]
+	}
+}
+
+/**
+第 i 个目标状态的权重，没有设置时为 1
+*/
+func weightAt(weights []float64, i int) float64 {
+	if i < len(weights) {
+		return weights[i]
+	}
+	return 1
+}
+
+/**
+目标状态的概率，没有设置权重时返回 nil
+*/
+func (transfer *Transition) probabilities() []float64 {
+	if transfer.Weights == nil || len(transfer.To) < 2 {
+		return nil
+	}
+	total := 0.0
+	for i := range transfer.To {
+		total += weightAt(transfer.Weights, i)
+	}
+	if total <= 0 {
+		return nil
+	}
+	var probabilities []float64
+	for i := range transfer.To {
+		probabilities = append(probabilities, weightAt(transfer.Weights, i)/total)
+	}
+	return probabilities
+}
+
+/**
+合并带权重的目标状态，去掉空状态 None，重复的目标状态保留第一次出现时的权重
+*/
+func mergeWeighted(transfer, newTransfer *Transition) ([]State, []float64) {
+	var to []State
+	var weights []float64
+	seen := map[State]bool{}
+	add := func(states []State, w []float64) {
+		for i, state := range states {
+			if seen[state] || state == None {
+				continue
+			}
+			seen[state] = true
+			to = append(to, state)
+			weights = append(weights, weightAt(w, i))
+		}
+	}
+	add(transfer.To, transfer.Weights)
+	add(newTransfer.To, newTransfer.Weights)
+	return to, weights
+}
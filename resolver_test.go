@@ -0,0 +1,178 @@
+package gofsm_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestWeightedResolver(t *testing.T) {
+	to := []gofsm.State{"a", "b", "c"}
+	tests := []struct {
+		name    string
+		weights []float64
+		want    map[gofsm.State]float64
+	}{
+		{"Uniform", nil, map[gofsm.State]float64{"a": 1.0 / 3, "b": 1.0 / 3, "c": 1.0 / 3}},
+		{"Weighted", []float64{0.5, 0.3, 0.2}, map[gofsm.State]float64{"a": 0.5, "b": 0.3, "c": 0.2}},
+		{"Zero Weight", []float64{0, 1, 0}, map[gofsm.State]float64{"b": 1}},
+		{"Default Weight", []float64{2}, map[gofsm.State]float64{"a": 0.5, "b": 0.25, "c": 0.25}},
+		{"All Zero", []float64{0, 0, 0}, map[gofsm.State]float64{"a": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolve := gofsm.WeightedResolver(42)
+			const n = 20000
+			counts := map[gofsm.State]float64{}
+			for i := 0; i < n; i++ {
+				counts[resolve("s", "e", to, tt.weights)]++
+			}
+			for state, count := range counts {
+				if math.Abs(count/n-tt.want[state]) > 0.02 {
+					t.Errorf("WeightedResolver() %v = %v, want %v", state, count/n, tt.want[state])
+				}
+			}
+		})
+	}
+
+	t.Run("Same Seed", func(t *testing.T) {
+		r1, r2 := gofsm.WeightedResolver(7), gofsm.WeightedResolver(7)
+		for i := 0; i < 100; i++ {
+			if a, b := r1("s", "e", to, nil), r2("s", "e", to, nil); a != b {
+				t.Fatalf("WeightedResolver() = %v and %v with the same seed", a, b)
+			}
+		}
+	})
+}
+
+func TestStateMachine_Resolver(t *testing.T) {
+	var actionTo []gofsm.State
+	action := func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (gofsm.State, error) {
+		actionTo = to
+		return to[0], nil
+	}
+	sm := gofsm.New("").
+		States(gofsm.StatesDef{"review": "", "approved": "", "rejected": ""}).
+		Events(gofsm.EventsDef{"decide": ""}).
+		Transitions(
+			gofsm.Transition{From: "review", Event: "decide", To: []gofsm.State{"approved"}, Action: action, Weights: []float64{0}},
+			gofsm.Transition{From: "review", Event: "decide", To: []gofsm.State{"rejected", "approved"}, Action: action, Weights: []float64{3, 1}},
+		)
+
+	if got := plantUMLScript(t, sm); !strings.Contains(got, "review --> approved : <font color=red><b>(decide) </b></font> p=0.00") ||
+		!strings.Contains(got, "review --> rejected : <font color=red><b>(decide) </b></font> p=1.00") {
+		t.Errorf("PlantUML script = %v, want probabilities", got)
+	}
+
+	got, err := sm.Resolver(gofsm.WeightedResolver(1)).Trigger(context.TODO(), "review", "decide")
+	if err != nil || got != "rejected" {
+		t.Errorf("StateMachine.Trigger() = %v, %v, want rejected", got, err)
+	}
+	if !reflect.DeepEqual(actionTo, []gofsm.State{"rejected"}) {
+		t.Errorf("Action to = %v, want [rejected]", actionTo)
+	}
+}
+
+func TestStateMachine_SeparateTransitions(t *testing.T) {
+	var calls []string
+	action := func(name string) gofsm.Action {
+		return func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (gofsm.State, error) {
+			calls = append(calls, fmt.Sprintf("%s %v", name, to))
+			return to[0], nil
+		}
+	}
+	pick := func(state gofsm.State) gofsm.Resolver {
+		return func(from gofsm.State, event gofsm.Event, to []gofsm.State, weights []float64) gofsm.State {
+			calls = append(calls, fmt.Sprintf("resolve %v", to))
+			return state
+		}
+	}
+	newMachine := func() *gofsm.StateMachine {
+		return gofsm.New("").
+			States(gofsm.StatesDef{"idle": "", "print": "", "scan": "", "copy": ""}).
+			Events(gofsm.EventsDef{"press": ""}).
+			SeparateTransitions(true).
+			Transitions(
+				gofsm.Transition{From: "idle", Event: "press", To: []gofsm.State{"print"}, Action: action("A")},
+				gofsm.Transition{From: "idle", Event: "press", To: []gofsm.State{"scan", "copy"}, Action: action("B")},
+			)
+	}
+
+	tests := []struct {
+		name     string
+		resolver gofsm.Resolver
+		want     gofsm.State
+		calls    []string
+	}{
+		{"No Resolver", nil, "print", []string{"A [print]"}},
+		{"Resolve First", pick("print"), "print", []string{"resolve [print scan copy]", "A [print]"}},
+		{"Resolve Second", pick("copy"), "copy", []string{"resolve [print scan copy]", "B [copy]"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = nil
+			got, err := newMachine().Resolver(tt.resolver).TriggerX(context.TODO(), "idle", "press")
+			if err != nil || got.State != tt.want {
+				t.Fatalf("StateMachine.TriggerX() = %v, %v, want %v", got.State, err, tt.want)
+			}
+			if !reflect.DeepEqual(calls, tt.calls) {
+				t.Errorf("calls = %v, want %v", calls, tt.calls)
+			}
+		})
+	}
+
+	t.Run("Not Merged", func(t *testing.T) {
+		if got := newMachine().Spec().Transitions; len(got) != 2 {
+			t.Errorf("StateMachine.Spec().Transitions = %v, want 2 transitions", got)
+		}
+	})
+}
+
+func TestStateMachine_TriggerX_Candidates(t *testing.T) {
+	pick := func(state gofsm.State) gofsm.Resolver {
+		return func(from gofsm.State, event gofsm.Event, to []gofsm.State, weights []float64) gofsm.State {
+			return state
+		}
+	}
+	last := func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (gofsm.State, error) {
+		return to[len(to)-1], nil
+	}
+	newMachine := func(resolver gofsm.Resolver) *gofsm.StateMachine {
+		return gofsm.New("").
+			States(gofsm.StatesDef{"review": "", "a": "", "b": "", "c": ""}).
+			Events(gofsm.EventsDef{"route": "", "skip": ""}).
+			Resolver(resolver).
+			Transitions(
+				gofsm.Transition{From: "review", Event: "route", To: []gofsm.State{"a", "b", "c"}, Action: last},
+				gofsm.Transition{From: "review", Event: "skip", To: []gofsm.State{"c"}, Action: last},
+			)
+	}
+
+	tests := []struct {
+		name       string
+		resolver   gofsm.Resolver
+		event      gofsm.Event
+		candidates []gofsm.State
+		chosen     gofsm.State
+	}{
+		{"Resolver", pick("b"), "route", []gofsm.State{"a", "b", "c"}, "b"},
+		{"Action Chooses", nil, "route", []gofsm.State{"a", "b", "c"}, "c"},
+		{"Single Target", nil, "skip", []gofsm.State{"c"}, "c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newMachine(tt.resolver).TriggerX(context.TODO(), "review", tt.event)
+			if err != nil || got.State != tt.chosen {
+				t.Fatalf("StateMachine.TriggerX() = %v, %v, want %v", got.State, err, tt.chosen)
+			}
+			if !reflect.DeepEqual(got.Candidates, tt.candidates) || got.Chosen != tt.chosen {
+				t.Errorf("StateMachine.TriggerX() candidates = %v, chosen = %v, want %v, %v", got.Candidates, got.Chosen, tt.candidates, tt.chosen)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package gofsm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+/**
+Action 失败重试配置，零值不重试
+	- Max     失败后最多重试次数
+	- Backoff 每次重试前等待的时间
+*/
+type Retry struct {
+	Max     int
+	Backoff time.Duration
+}
+
+/**
+可以声明是否重试的错误
+Action 返回的错误（或其 Unwrap 链上的错误）实现该接口且 Retryable() 返回 false 时不再重试，
+直接进入 OnActionFailure；没有实现该接口的错误按 Retry 配置重试
+*/
+type RetryableError interface {
+	error
+	Retryable() bool
+}
+
+/**
+不可重试的错误，通过 Fatal 创建，errors.Is/As 可以穿透到原始错误
+*/
+type FatalError struct {
+	Err error
+}
+
+/**
+标记错误不可重试，例如下游返回 4xx 时重试没有意义
+*/
+func Fatal(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &FatalError{Err: err}
+}
+
+func (e *FatalError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FatalError) Unwrap() error {
+	return e.Err
+}
+
+func (e *FatalError) Retryable() bool {
+	return false
+}
+
+/**
+错误是否可以重试：panic 和 Retryable() 返回 false 的错误不重试
+*/
+func retryable(err error) bool {
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) {
+		return false
+	}
+	var retryErr RetryableError
+	if errors.As(err, &retryErr) {
+		return retryErr.Retryable()
+	}
+	return true
+}
+
+/**
+执行转换的 Action，失败时按 Retry 配置重试，全部失败后返回最后一次的错误
+不可重试的错误直接返回；等待重试时 ctx 结束返回同时包装 ctx 错误和最后一次错误的错误
+*/
+func (sm *StateMachine) runWithRetry(ctx context.Context, transfer *Transition, from State, event Event, targets []State, opts triggerOptions) (to State, err error) {
+	for attempt := 0; ; attempt++ {
+		err = sm.safely(func() (err error) {
+			to, err = transfer.run(ctx, from, event, targets, opts)
+			return err
+		})
+		if err == nil || attempt >= transfer.Retry.Max || !retryable(err) {
+			return to, err
+		}
+		sm.debugf("retry %s --(%s)--> %v: %d/%d, %v", from, event, targets, attempt+1, transfer.Retry.Max, err)
+		if ctxErr := sleepContext(ctx, transfer.Retry.Backoff); ctxErr != nil {
+			return None, &joinedError{errs: []error{ctxErr, err}}
+		}
+	}
+}
+
+/**
+同时包装多个错误，errors.Is/As 对任意一个错误成立时成立
+go 1.20 之前 errors.Is/As 不识别 Unwrap() []error，所以同时实现 Is 和 As
+*/
+type joinedError struct {
+	errs []error
+}
+
+func (e *joinedError) Error() string {
+	messages := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, ": ")
+}
+
+func (e *joinedError) Unwrap() []error {
+	return e.errs
+}
+
+func (e *joinedError) Is(target error) bool {
+	for _, err := range e.errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *joinedError) As(target interface{}) bool {
+	for _, err := range e.errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
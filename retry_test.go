@@ -0,0 +1,129 @@
+package gofsm_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestTransition_Retry(t *testing.T) {
+	tests := []struct {
+		name     string
+		retry    gofsm.Retry
+		failures int
+		want     gofsm.State
+		wantErr  bool
+		attempts int
+	}{
+		{"No Retry", gofsm.Retry{}, 1, "error", true, 1},
+		{"Success After Retry", gofsm.Retry{Max: 3, Backoff: time.Millisecond}, 2, "paid", false, 3},
+		{"Retries Exhausted", gofsm.Retry{Max: 2}, 5, "error", true, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			action := func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (gofsm.State, error) {
+				attempts++
+				if attempts <= tt.failures {
+					return gofsm.None, errors.New("downstream error")
+				}
+				return to[0], nil
+			}
+			sm := newOrderMachine().
+				States(gofsm.StatesDef{"new": "", "paid": "", "sent": "", "error": ""}).
+				ErrorState("error").
+				Events(gofsm.EventsDef{"retry": ""}).
+				Transitions(gofsm.Transition{From: "new", Event: "retry", To: []gofsm.State{"paid"}, Action: action, Retry: tt.retry})
+
+			got, err := sm.Trigger(context.TODO(), "new", "retry")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Trigger() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Trigger() = %v, want %v", got, tt.want)
+			}
+			if attempts != tt.attempts {
+				t.Errorf("attempts = %v, want %v", attempts, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestTransition_Retry_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	attempts := 0
+	downstream := statusError(503)
+	action := func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (gofsm.State, error) {
+		attempts++
+		cancel()
+		return gofsm.None, downstream
+	}
+	processor := &failureProcessor{}
+	sm := newOrderMachine().
+		Events(gofsm.EventsDef{"retry": ""}).
+		Processor(processor).
+		Transitions(gofsm.Transition{From: "new", Event: "retry", To: []gofsm.State{"paid"}, Action: action, Retry: gofsm.Retry{Max: 5, Backoff: time.Hour}})
+	_, err := sm.Trigger(ctx, "new", "retry")
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, downstream) || !strings.Contains(err.Error(), "status 503") {
+		t.Errorf("Trigger() error = %v, want %v and %v", err, context.Canceled, downstream)
+	}
+	var status statusError
+	if !errors.As(err, &status) || status != downstream {
+		t.Errorf("errors.As() = %v, want %v", status, downstream)
+	}
+	if len(processor.failures) != 1 || processor.failures[0] != err {
+		t.Errorf("OnActionFailure errors = %v, want [%v]", processor.failures, err)
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %v, want 1", attempts)
+	}
+}
+
+type statusError int
+
+func (e statusError) Error() string   { return fmt.Sprintf("status %d", int(e)) }
+func (e statusError) Retryable() bool { return e >= 500 }
+
+func TestTransition_Retry_Fatal(t *testing.T) {
+	downstream := errors.New("bad request")
+	tests := []struct {
+		name     string
+		err      error
+		attempts int
+	}{
+		{"Plain Error", downstream, 4},
+		{"Fatal", gofsm.Fatal(downstream), 1},
+		{"Retryable 503", statusError(503), 4},
+		{"Not Retryable 400", statusError(400), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			action := func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (gofsm.State, error) {
+				attempts++
+				return gofsm.None, tt.err
+			}
+			sm := newOrderMachine().
+				Events(gofsm.EventsDef{"retry": ""}).
+				Transitions(gofsm.Transition{From: "new", Event: "retry", To: []gofsm.State{"paid"}, Action: action, Retry: gofsm.Retry{Max: 3}})
+
+			if _, err := sm.Trigger(context.TODO(), "new", "retry"); !errors.Is(err, tt.err) {
+				t.Errorf("Trigger() error = %v, want %v", err, tt.err)
+			}
+			if attempts != tt.attempts {
+				t.Errorf("attempts = %v, want %v", attempts, tt.attempts)
+			}
+		})
+	}
+	if err := gofsm.Fatal(downstream); !errors.Is(err, downstream) {
+		t.Errorf("Fatal() does not unwrap to %v", downstream)
+	}
+	if gofsm.Fatal(nil) != nil {
+		t.Errorf("Fatal(nil) want nil")
+	}
+}
@@ -0,0 +1,74 @@
+package gofsm
+
+import "sync/atomic"
+
+/**
+封存状态机：完成构建并建立索引，Trigger 查找转换只需要一次 map 查找
+封存后状态机只读，再调用 Transitions、States 等构建方法会 panic，
+因此可以在多个 goroutine 之间共享而不需要加锁
+*/
+func (sm *StateMachine) Seal() *StateMachine {
+	sm.sg.buildIndex(sm.epsilon, sm.sortTargets)
+	sm.sealed = true
+	return sm
+}
+
+/**
+状态机是否已封存
+*/
+func (sm *StateMachine) Sealed() bool {
+	return sm.sealed
+}
+
+/**
+构建方法调用前检查状态机是否可以修改
+封存或者已经创建实例的状态机不能修改，需要先 Clone
+*/
+func (sm *StateMachine) mutable() {
+	if sm.sealed {
+		panic(ErrSealed)
+	}
+	if atomic.LoadInt32(&sm.inUse) != 0 {
+		panic(ErrInUse)
+	}
+	sm.sg.invalidateDiagram()
+}
+
+/**
+去掉 Transitions 合并后目标状态中的重复和空状态，sortTargets 时按名称排序，然后建立索引
+*/
+func (sg *stateGraph) buildIndex(epsilon, sortTargets bool) {
+	for transfer := range sg.pending {
+		if transfer.Weights == nil && len(transfer.To) > 0 {
+			transfer.To = removeRepByMap(transfer.To)
+		}
+		if sortTargets {
+			transfer.sortTargets()
+		}
+	}
+	sg.pending = nil
+
+	index := map[State][]Event{}
+	for from := range sg.transitions {
+		index[from] = sg.scanEvents(from, epsilon)
+	}
+	sg.eventIndex = index
+
+	compiled := map[transitionKey][]*Transition{}
+	for from, events := range sg.transitions {
+		for event, transfers := range events {
+			compiled[transitionKey{from, event}] = transfers
+		}
+	}
+	sg.compiled = compiled
+}
+
+/**
+from 状态上 event 事件的转换，按优先级排序，封存后使用 Seal 时展开的索引，只需要一次查找
+*/
+func (sg *stateGraph) lookup(from State, event Event) []*Transition {
+	if sg.compiled != nil {
+		return sg.compiled[transitionKey{from, event}]
+	}
+	return sg.transitions[from][event]
+}
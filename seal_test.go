@@ -0,0 +1,125 @@
+package gofsm_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestStateMachine_AvailableEvents(t *testing.T) {
+	newMachine := func() *gofsm.StateMachine {
+		return newOrderMachine().Transitions(
+			gofsm.Transition{From: "new", Event: "cancel", To: []gofsm.State{"sent"}, Action: gofsm.NoopAction},
+		)
+	}
+	tests := []struct {
+		name  string
+		state gofsm.State
+		want  []gofsm.Event
+	}{
+		{"Multiple", "new", []gofsm.Event{"cancel", "pay"}},
+		{"Single", "paid", []gofsm.Event{"send"}},
+		{"None", "sent", nil},
+		{"Unknown", "unknown", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newMachine().AvailableEvents(tt.state); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StateMachine.AvailableEvents() = %v, want %v", got, tt.want)
+			}
+			if got := newMachine().Seal().AvailableEvents(tt.state); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Sealed StateMachine.AvailableEvents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStateMachine_Seal(t *testing.T) {
+	sm := newOrderMachine()
+	if sm.Sealed() {
+		t.Errorf("StateMachine.Sealed() = true before Seal")
+	}
+	if !sm.Seal().Sealed() {
+		t.Errorf("StateMachine.Sealed() = false after Seal")
+	}
+}
+
+func TestStateMachine_Seal_Trigger(t *testing.T) {
+	sm := newOrderMachine().Processor(gofsm.NoopProcessor).Seal()
+	tests := []struct {
+		name    string
+		from    gofsm.State
+		event   gofsm.Event
+		disable bool
+		want    gofsm.State
+		wantErr error
+	}{
+		{"Defined", "new", "pay", false, "paid", nil},
+		{"Undefined", "paid", "pay", false, "paid", gofsm.ErrNoTransition},
+		{"Disabled", "new", "pay", true, "new", gofsm.ErrNoTransition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.disable {
+				sm.Disable(tt.from, tt.event)
+				defer sm.Enable(tt.from, tt.event)
+			}
+			got, err := sm.Trigger(context.TODO(), tt.from, tt.event)
+			if !errors.Is(err, tt.wantErr) || (err == nil && got != tt.want) {
+				t.Errorf("Sealed StateMachine.Trigger() = %v, %v, want %v, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStateMachine_Seal_DedupTargets(t *testing.T) {
+	sm := gofsm.New("").SortTargets(true)
+	for _, to := range []gofsm.State{"c", "b", gofsm.None, "c", "b"} {
+		sm.Transitions(gofsm.Transition{From: "a", Event: "e", To: []gofsm.State{to}, Action: gofsm.NoopAction})
+	}
+	if got := sm.Spec().Transitions[0].To; !reflect.DeepEqual(got, []gofsm.State{"c", "b", "c", "b"}) {
+		t.Errorf("unsealed To = %v, want appended targets without None", got)
+	}
+	clone := sm.Clone()
+	for _, m := range []*gofsm.StateMachine{sm.Seal(), clone.Seal()} {
+		if got := m.Spec().Transitions[0].To; !reflect.DeepEqual(got, []gofsm.State{"b", "c"}) {
+			t.Errorf("sealed To = %v, want [b c]", got)
+		}
+	}
+}
+
+func TestStateMachine_Seal_Immutable(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(sm *gofsm.StateMachine)
+	}{
+		{"Transitions", func(sm *gofsm.StateMachine) {
+			sm.Transitions(gofsm.Transition{From: "new", Event: "send", To: []gofsm.State{"sent"}, Action: gofsm.NoopAction})
+		}},
+		{"States", func(sm *gofsm.StateMachine) { sm.States(gofsm.StatesDef{}) }},
+		{"Events", func(sm *gofsm.StateMachine) { sm.Events(gofsm.EventsDef{}) }},
+		{"Start", func(sm *gofsm.StateMachine) { sm.Start(nil) }},
+		{"End", func(sm *gofsm.StateMachine) { sm.End(nil) }},
+		{"Processor", func(sm *gofsm.StateMachine) { sm.Processor(gofsm.NoopProcessor) }},
+		{"AliasEvent", func(sm *gofsm.StateMachine) { sm.AliasEvent("pay", "pay2") }},
+		{"Use", func(sm *gofsm.StateMachine) { sm.Use() }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newOrderMachine().Seal()
+			defer func() {
+				if r := recover(); r != gofsm.ErrSealed {
+					t.Errorf("recover() = %v, want %v", r, gofsm.ErrSealed)
+				}
+			}()
+			tt.build(sm)
+		})
+	}
+
+	t.Run("Seal Twice", func(t *testing.T) {
+		newOrderMachine().Seal().Seal()
+	})
+}
@@ -0,0 +1,51 @@
+package gofsm
+
+import (
+	"context"
+	"time"
+)
+
+/**
+状态停留时间告警
+*/
+type sla struct {
+	limit    time.Duration
+	onBreach func(ctx context.Context, state State, elapsed time.Duration)
+}
+
+/**
+定义状态停留时间告警：实例在 state 停留超过 d 时调用 onBreach，只告警，不改变状态
+只在 Instance.Run 中生效，进入 state 时开始计时，离开时取消，每次进入最多告警一次
+每个状态只能有一个告警，重复定义时覆盖，与 After 定义的定时转换互不影响
+*/
+func (sm *StateMachine) StateSLA(state State, d time.Duration, onBreach func(ctx context.Context, state State, elapsed time.Duration)) *StateMachine {
+	sm.mutable()
+	if sm.sg.slas == nil {
+		sm.sg.slas = map[State]*sla{}
+	}
+	sm.sg.slas[sm.normState(state)] = &sla{limit: d, onBreach: onBreach}
+	return sm
+}
+
+func (i *Instance) armSLA(old *stateTimer) *stateTimer {
+	old.stop()
+	current := i.Current()
+	s, ok := i.sm.sg.slas[current]
+	if !ok {
+		return &stateTimer{state: current}
+	}
+	timer := time.NewTimer(s.limit)
+	return &stateTimer{state: current, timer: timer, C: timer.C, since: time.Now()}
+}
+
+/**
+告警计时到期，实例已经离开计时的状态（例如在 Run 之外调用了 Fire）时不告警
+*/
+func (i *Instance) breach(ctx context.Context, alarm *stateTimer) {
+	if i.Current() != alarm.state {
+		return
+	}
+	if s := i.sm.sg.slas[alarm.state]; s.onBreach != nil {
+		s.onBreach(ctx, alarm.state, time.Since(alarm.since))
+	}
+}
@@ -0,0 +1,58 @@
+package gofsm_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestInstance_Run_StateSLA(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []gofsm.Event
+		want   []string
+	}{
+		{"Breach", nil, []string{"new"}},
+		{"Left In Time", []gofsm.Event{"pay"}, nil},
+		{"Next State Within SLA", []gofsm.Event{"pay", "send"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			breaches := make(chan string, 10)
+			onBreach := func(ctx context.Context, state gofsm.State, elapsed time.Duration) {
+				if elapsed < 20*time.Millisecond {
+					breaches <- fmt.Sprintf("%s early %v", state, elapsed)
+					return
+				}
+				breaches <- string(state)
+			}
+			ins := newOrderMachine().
+				StateSLA("new", 20*time.Millisecond, onBreach).
+				StateSLA("sent", time.Hour, onBreach).
+				NewInstance("new")
+			for _, event := range tt.events {
+				ins.Post(event)
+			}
+			go ins.Run(ctx)
+
+			time.Sleep(60 * time.Millisecond)
+			cancel()
+			var got []string
+			for len(breaches) > 0 {
+				got = append(got, <-breaches)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("breaches = %v, want %v", got, tt.want)
+			}
+			if tt.events == nil && ins.Current() != "new" {
+				t.Errorf("Instance.Current() = %v, want new", ins.Current())
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package gofsm
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+/**
+实例运行时状态的快照，可以序列化为 JSON 保存，用于测试和迁移
+不包含 OnComplete、Observer 等回调，恢复后需要重新设置
+*/
+type InstanceSnapshot struct {
+	State     State            `json:"state"`
+	History   []SnapshotRecord `json:"history,omitempty"`
+	Steps     int              `json:"steps,omitempty"`
+	Budget    int              `json:"budget,omitempty"`
+	Completed bool             `json:"completed,omitempty"`
+}
+
+/**
+快照中的历史记录，Err 只保存错误信息
+*/
+type SnapshotRecord struct {
+	Kind  RecordKind `json:"kind"`
+	From  State      `json:"from,omitempty"`
+	Event Event      `json:"event,omitempty"`
+	To    State      `json:"to"`
+	Time  time.Time  `json:"time"`
+	Err   string     `json:"err,omitempty"`
+}
+
+/**
+获取实例当前状态、历史记录和转换计数的快照
+*/
+func (i *Instance) Snapshot() InstanceSnapshot {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	snapshot := InstanceSnapshot{State: i.current, Steps: i.steps, Budget: i.budget, Completed: i.completed}
+	for _, r := range i.history {
+		record := SnapshotRecord{Kind: r.Kind, From: r.From, Event: r.Event, To: r.To, Time: r.Time}
+		if r.Err != nil {
+			record.Err = r.Err.Error()
+		}
+		snapshot.History = append(snapshot.History, record)
+	}
+	return snapshot
+}
+
+/**
+根据快照创建实例，快照中的当前状态必须是状态机中的状态（StrictMembership(false) 时不检查）
+状态机可以与创建快照时不同，例如升级后的版本
+*/
+func (sm *StateMachine) RestoreInstance(snapshot InstanceSnapshot) (*Instance, error) {
+	state := sm.normState(snapshot.State)
+	if !sm.sg.contains(state) && !sm.permissive {
+		return nil, fmt.Errorf("%w%s", ErrUnknownState, state)
+	}
+	i := sm.NewInstance(state)
+	i.steps, i.budget, i.completed = snapshot.Steps, snapshot.Budget, snapshot.Completed
+	for _, r := range snapshot.History {
+		record := Record{Kind: r.Kind, From: r.From, Event: r.Event, To: r.To, Time: r.Time}
+		if r.Err != "" {
+			record.Err = errors.New(r.Err)
+		}
+		i.history = append(i.history, record)
+	}
+	return i, nil
+}
@@ -0,0 +1,53 @@
+package gofsm_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestInstance_Snapshot(t *testing.T) {
+	failure := func(ctx context.Context, from gofsm.State, event gofsm.Event, to []gofsm.State) (gofsm.State, error) {
+		return "", errors.New("action error")
+	}
+	sm := newOrderMachine().
+		States(gofsm.StatesDef{"new": "", "paid": "", "sent": "", "error": ""}).
+		ErrorState("error").
+		Transitions(gofsm.Transition{From: "paid", Event: "pay", To: []gofsm.State{"paid"}, Action: failure})
+
+	i := sm.NewInstance("new").SetStepBudget(3)
+	_, _ = i.Fire(context.TODO(), "pay")
+	_, _ = i.Fire(context.TODO(), "pay")
+
+	data, err := json.Marshal(i.Snapshot())
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var snapshot gofsm.InstanceSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if snapshot.State != "error" || snapshot.Steps != 1 || snapshot.Budget != 3 || len(snapshot.History) != 2 ||
+		snapshot.History[1].Err != "action error" {
+		t.Fatalf("Instance.Snapshot() = %+v", snapshot)
+	}
+
+	restored, err := sm.Clone().RestoreInstance(snapshot)
+	if err != nil {
+		t.Fatalf("StateMachine.RestoreInstance() error = %v", err)
+	}
+	if got := restored.Snapshot(); !reflect.DeepEqual(got, snapshot) {
+		t.Errorf("restored Instance.Snapshot() = %+v, want %+v", got, snapshot)
+	}
+	if got := restored.History()[1].Err; got == nil || got.Error() != "action error" {
+		t.Errorf("restored Record.Err = %v, want action error", got)
+	}
+
+	if _, err := newOrderMachine().RestoreInstance(snapshot); !errors.Is(err, gofsm.ErrUnknownState) {
+		t.Errorf("StateMachine.RestoreInstance() error = %v, want %v", err, gofsm.ErrUnknownState)
+	}
+}
@@ -0,0 +1,69 @@
+package gofsm
+
+/**
+状态机拓扑定义，用于导出和导入（不包含 Action、Guard、Processor 等函数）
+转换按 from、event 排序，相同的状态机总是导出相同的结果
+*/
+type Spec struct {
+	Name        string           `json:"name"`
+	States      StatesDef        `json:"states"`
+	Events      EventsDef        `json:"events"`
+	Aliases     map[Event]Event  `json:"aliases,omitempty"`
+	Start       []State          `json:"start,omitempty"`
+	End         []State          `json:"end,omitempty"`
+	ErrorState  State            `json:"errorState,omitempty"`
+	Transitions []SpecTransition `json:"transitions"`
+}
+
+type SpecTransition struct {
+	From     State     `json:"from"`
+	Event    Event     `json:"event"`
+	To       []State   `json:"to"`
+	Priority int       `json:"priority,omitempty"`
+	Weights  []float64 `json:"weights,omitempty"`
+	Guarded  bool      `json:"guarded,omitempty"`
+	Tags     []string  `json:"tags,omitempty"`
+	Desc     string    `json:"desc,omitempty"`
+}
+
+/**
+导出状态机拓扑定义
+*/
+func (sm *StateMachine) Spec() *Spec {
+	sg := sm.sg
+	spec := &Spec{
+		Name:        sg.name,
+		States:      StatesDef{},
+		Events:      EventsDef{},
+		Start:       append([]State(nil), sg.start...),
+		End:         append([]State(nil), sg.end...),
+		ErrorState:  sg.errorState,
+		Transitions: []SpecTransition{},
+	}
+	for state, desc := range sg.states {
+		spec.States[state] = desc
+	}
+	for event, desc := range sg.events {
+		spec.Events[event] = desc
+	}
+	if len(sg.aliases) > 0 {
+		spec.Aliases = map[Event]Event{}
+		for alias, primary := range sg.aliases {
+			spec.Aliases[alias] = primary
+		}
+	}
+	sg.each(func(transfer *Transition) bool {
+		spec.Transitions = append(spec.Transitions, SpecTransition{
+			From:     transfer.From,
+			Event:    transfer.Event,
+			To:       append([]State{}, transfer.To...),
+			Priority: transfer.Priority,
+			Weights:  append([]float64(nil), transfer.Weights...),
+			Guarded:  transfer.guarded(),
+			Tags:     append([]string(nil), transfer.Tags...),
+			Desc:     transfer.Desc,
+		})
+		return true
+	})
+	return spec
+}
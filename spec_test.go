@@ -0,0 +1,27 @@
+package gofsm_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestStateMachine_Spec(t *testing.T) {
+	sm := newOrderMachine().AliasEvent("pay", "pay_again")
+	data, err := json.Marshal(sm.Spec())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"name":"order","states":{"imported":"导入","new":"新建","paid":"已支付","sent":"已发货"},` +
+		`"events":{"pay":"支付","send":"发货"},"aliases":{"pay_again":"pay"},"start":["new"],"end":["sent"],` +
+		`"transitions":[{"from":"new","event":"pay","to":["paid"]},{"from":"paid","event":"send","to":["sent"]}]}`
+	if string(data) != want {
+		t.Errorf("StateMachine.Spec() = %s, want %s", data, want)
+	}
+
+	empty, _ := json.Marshal(gofsm.New("").Spec())
+	if want := `{"name":"","states":{},"events":{},"transitions":[]}`; string(empty) != want {
+		t.Errorf("StateMachine.Spec() = %s, want %s", empty, want)
+	}
+}
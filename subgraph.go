@@ -0,0 +1,125 @@
+package gofsm
+
+/**
+提取 state 附近的子图：state、depth 步以内的前驱状态和后继状态，以及这些状态之间的转换
+用于只显示关心的部分，转换保留 Action、Guard 等，NFA 转换只保留子图中的目标状态
+state 不存在时返回空的状态机
+*/
+func (sm *StateMachine) Neighborhood(state State, depth int) *StateMachine {
+	sg := sm.sg
+	sub := New(sg.name).PlantUMLTheme(sg.theme)
+	if _, ok := sg.states[state]; !ok {
+		return sub.States(StatesDef{}).Events(EventsDef{})
+	}
+
+	successors := map[State][]State{}
+	predecessors := map[State][]State{}
+	sg.each(func(transfer *Transition) bool {
+		for _, to := range transfer.To {
+			successors[transfer.From] = append(successors[transfer.From], to)
+			predecessors[to] = append(predecessors[to], transfer.From)
+		}
+		return true
+	})
+	included := map[State]bool{state: true}
+	for _, next := range []map[State][]State{successors, predecessors} {
+		frontier := []State{state}
+		for step := 0; step < depth && len(frontier) > 0; step++ {
+			var more []State
+			for _, s := range frontier {
+				for _, n := range next[s] {
+					if _, ok := sg.states[n]; ok && !included[n] {
+						included[n] = true
+						more = append(more, n)
+					}
+				}
+			}
+			frontier = more
+		}
+	}
+
+	var transitions []Transition
+	sg.each(func(transfer *Transition) bool {
+		if !included[transfer.From] {
+			return true
+		}
+		copied := *transfer
+		copied.To, copied.Weights = nil, nil
+		for i, to := range transfer.To {
+			if included[to] {
+				copied.To = append(copied.To, to)
+				if transfer.Weights != nil {
+					copied.Weights = append(copied.Weights, weightAt(transfer.Weights, i))
+				}
+			}
+		}
+		if len(copied.To) == 0 {
+			return true
+		}
+		transitions = append(transitions, copied)
+		return true
+	})
+	return sg.subMachine(sub, included, transitions)
+}
+
+/**
+只包含标签 tag 的转换和这些转换涉及的状态的状态机，用于给不同角色显示各自可以执行的操作
+转换保留 Action、Guard 等，开始、结束状态只保留包含的状态，结果可以 Validate
+*/
+func (sm *StateMachine) ProjectForTag(tag string) *StateMachine {
+	sg := sm.sg
+	included := map[State]bool{}
+	var transitions []Transition
+	sg.each(func(transfer *Transition) bool {
+		if !containsTag(transfer.Tags, tag) {
+			return true
+		}
+		copied := *transfer
+		copied.To = append([]State(nil), transfer.To...)
+		copied.Weights = append([]float64(nil), transfer.Weights...)
+		copied.Tags = append([]string(nil), transfer.Tags...)
+		for _, s := range append([]State{transfer.From}, transfer.To...) {
+			if s != None && !reservedState(s) {
+				included[s] = true
+			}
+		}
+		transitions = append(transitions, copied)
+		return true
+	})
+	return sg.subMachine(New(sg.name).PlantUMLTheme(sg.theme), included, transitions)
+}
+
+/**
+用 included 中的状态和 transitions 组成子状态机，事件、开始、结束状态和事件别名只保留用到的
+*/
+func (sg *stateGraph) subMachine(sub *StateMachine, included map[State]bool, transitions []Transition) *StateMachine {
+	states := StatesDef{}
+	for s := range included {
+		states[s] = sg.states[s]
+	}
+	events := EventsDef{}
+	for _, transfer := range transitions {
+		if desc, ok := sg.events[transfer.Event]; ok {
+			events[transfer.Event] = desc
+		}
+	}
+
+	var start, end []State
+	for _, s := range sg.start {
+		if included[s] {
+			start = append(start, s)
+		}
+	}
+	for _, s := range sg.end {
+		if included[s] {
+			end = append(end, s)
+		}
+	}
+	sub.States(states).Events(events).Start(start).End(end).Transitions(transitions...)
+	for alias, primary := range sg.aliases {
+		if _, ok := events[primary]; ok {
+			sub.AliasEvent(primary, alias)
+		}
+	}
+	return sub
+}
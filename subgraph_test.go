@@ -0,0 +1,107 @@
+package gofsm_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestStateMachine_Neighborhood(t *testing.T) {
+	chain := gofsm.New("chain").
+		States(gofsm.StatesDef{"s1": "", "s2": "", "s3": "", "s4": "", "s5": ""}).
+		Events(gofsm.EventsDef{"next": "", "jump": ""}).
+		Start([]gofsm.State{"s1"}).
+		End([]gofsm.State{"s5"}).
+		Transitions(
+			gofsm.Transition{From: "s1", Event: "next", To: []gofsm.State{"s2"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "s2", Event: "next", To: []gofsm.State{"s3"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "s3", Event: "next", To: []gofsm.State{"s4"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "s4", Event: "next", To: []gofsm.State{"s5"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "s4", Event: "jump", To: []gofsm.State{"s1", "s5"}, Action: gofsm.NoopAction},
+		)
+
+	tests := []struct {
+		name  string
+		state gofsm.State
+		depth int
+		want  []string
+	}{
+		{"Depth 0", "s3", 0, nil},
+		{"Depth 1", "s3", 1, []string{"s2 next s3", "s3 next s4"}},
+		{"NFA Filtered", "s5", 1, []string{"s4 jump s5", "s4 next s5"}},
+		{"Unknown", "s9", 3, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			chain.Neighborhood(tt.state, tt.depth).EachTransition(func(from gofsm.State, event gofsm.Event, to gofsm.State) bool {
+				got = append(got, string(from)+" "+string(event)+" "+string(to))
+				return true
+			})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StateMachine.Neighborhood() transitions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("Start End", func(t *testing.T) {
+		spec := chain.Neighborhood("s3", 1).Spec()
+		if len(spec.End) != 0 || len(spec.Start) != 0 || len(spec.States) != 3 {
+			t.Errorf("StateMachine.Neighborhood().Spec() = %+v", spec)
+		}
+		spec = chain.Neighborhood("s2", 1).Spec()
+		if !reflect.DeepEqual(spec.Start, []gofsm.State{"s1"}) || len(spec.End) != 0 {
+			t.Errorf("StateMachine.Neighborhood().Spec() = %+v", spec)
+		}
+		if err := chain.Neighborhood("s4", 1).Validate(); err != nil {
+			t.Errorf("StateMachine.Neighborhood().Validate() error = %v", err)
+		}
+	})
+}
+
+func TestStateMachine_ProjectForTag(t *testing.T) {
+	sm := gofsm.New("order").
+		States(gofsm.StatesDef{"new": "", "paid": "", "sent": "", "refunded": ""}).
+		Events(gofsm.EventsDef{"pay": "", "send": "", "refund": ""}).
+		Start([]gofsm.State{"new"}).
+		End([]gofsm.State{"sent", "refunded"}).
+		Transitions(
+			gofsm.Transition{From: "new", Event: "pay", To: []gofsm.State{"paid"}, Action: gofsm.NoopAction, Tags: []string{"customer"}},
+			gofsm.Transition{From: "paid", Event: "send", To: []gofsm.State{"sent"}, Action: gofsm.NoopAction, Tags: []string{"warehouse"}},
+			gofsm.Transition{From: "paid", Event: "refund", To: []gofsm.State{"refunded"}, Action: gofsm.NoopAction, Tags: []string{"customer", "support"}},
+		)
+
+	tests := []struct {
+		name   string
+		tag    string
+		want   []string
+		states int
+		start  []gofsm.State
+		end    []gofsm.State
+	}{
+		{"Customer", "customer", []string{"new pay paid", "paid refund refunded"}, 3, []gofsm.State{"new"}, []gofsm.State{"refunded"}},
+		{"Warehouse", "warehouse", []string{"paid send sent"}, 2, nil, []gofsm.State{"sent"}},
+		{"Unknown", "admin", nil, 0, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projected := sm.ProjectForTag(tt.tag)
+			var got []string
+			projected.EachTransition(func(from gofsm.State, event gofsm.Event, to gofsm.State) bool {
+				got = append(got, string(from)+" "+string(event)+" "+string(to))
+				return true
+			})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StateMachine.ProjectForTag() transitions = %v, want %v", got, tt.want)
+			}
+			spec := projected.Spec()
+			if len(spec.States) != tt.states || !reflect.DeepEqual(spec.Start, tt.start) || !reflect.DeepEqual(spec.End, tt.end) {
+				t.Errorf("StateMachine.ProjectForTag().Spec() = %+v", spec)
+			}
+			if err := projected.Validate(); err != nil {
+				t.Errorf("StateMachine.ProjectForTag().Validate() error = %v", err)
+			}
+		})
+	}
+}
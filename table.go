@@ -0,0 +1,98 @@
+package gofsm
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+/**
+转换矩阵的文本表格，行是状态，列是事件，单元格是目标状态，多个目标状态用逗号分隔
+行列按名称排序，没有转换的单元格为空，ε 转换的列名为 ε
+*/
+func (sm *StateMachine) Table() string {
+	sg := sm.sg
+	stateSet := map[State]bool{}
+	for state := range sg.states {
+		stateSet[state] = true
+	}
+	eventSet := map[Event]bool{}
+	for event := range sg.events {
+		eventSet[event] = true
+	}
+	cells := map[State]map[Event][]string{}
+	sg.each(func(transfer *Transition) bool {
+		stateSet[transfer.From] = true
+		eventSet[transfer.Event] = true
+		if cells[transfer.From] == nil {
+			cells[transfer.From] = map[Event][]string{}
+		}
+		for _, to := range transfer.To {
+			cells[transfer.From][transfer.Event] = append(cells[transfer.From][transfer.Event], string(to))
+		}
+		return true
+	})
+
+	var states []State
+	for state := range stateSet {
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i] < states[j] })
+	var events []Event
+	for event := range eventSet {
+		events = append(events, event)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i] < events[j] })
+
+	header := []string{""}
+	for _, event := range events {
+		if event == None {
+			header = append(header, "ε")
+		} else {
+			header = append(header, string(event))
+		}
+	}
+	rows := [][]string{header}
+	for _, state := range states {
+		row := []string{string(state)}
+		for _, event := range events {
+			row = append(row, strings.Join(cells[state][event], ","))
+		}
+		rows = append(rows, row)
+	}
+	return formatTable(rows)
+}
+
+/**
+按列对齐输出表格，第一行是表头
+*/
+func formatTable(rows [][]string) string {
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	var b strings.Builder
+	line := func(row []string) {
+		for i, cell := range row {
+			b.WriteString("| ")
+			b.WriteString(cell)
+			b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+1))
+		}
+		b.WriteString("|\n")
+	}
+	line(rows[0])
+	for i := range widths {
+		b.WriteString("|")
+		b.WriteString(strings.Repeat("-", widths[i]+2))
+	}
+	b.WriteString("|\n")
+	for _, row := range rows[1:] {
+		line(row)
+	}
+	return b.String()
+}
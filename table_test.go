@@ -0,0 +1,38 @@
+package gofsm_test
+
+import (
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestStateMachine_Table(t *testing.T) {
+	tests := []struct {
+		name string
+		sm   *gofsm.StateMachine
+		want string
+	}{
+		{"DFA", newOrderMachine(), "" +
+			"|          | pay  | send |\n" +
+			"|----------|------|------|\n" +
+			"| imported |      |      |\n" +
+			"| new      | paid |      |\n" +
+			"| paid     |      | sent |\n" +
+			"| sent     |      |      |\n"},
+		{"NFA", gofsm.New("").Transitions(
+			gofsm.Transition{From: "a", Event: "e1", To: []gofsm.State{"b", "c"}, Action: gofsm.NoopAction},
+			gofsm.Transition{From: "b", Event: gofsm.None, To: []gofsm.State{"c"}, Action: gofsm.NoopAction},
+		), "" +
+			"|   | ε | e1  |\n" +
+			"|---|---|-----|\n" +
+			"| a |   | b,c |\n" +
+			"| b | c |     |\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sm.Table(); got != tt.want {
+				t.Errorf("StateMachine.Table() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
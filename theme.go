@@ -0,0 +1,82 @@
+package gofsm
+
+import "strings"
+
+/**
+PlantUML 图的样式
+	- NFAColor: 非确定状态的背景色
+	- TerminalColor: 结束状态的背景色，为空时不设置
+	- Title: 图的标题
+	- Direction: 布局方向，LR 从左到右，TB 从上到下，为空时使用 PlantUML 默认布局
+*/
+type PlantUMLTheme struct {
+	NFAColor      string
+	TerminalColor string
+	Title         string
+	Direction     string
+}
+
+/**
+默认样式
+*/
+var DefaultPlantUMLTheme = PlantUMLTheme{
+	NFAColor: "Red",
+	Title:    "State Graph",
+}
+
+/**
+设置 PlantUML 图的样式，没有设置的字段使用 DefaultPlantUMLTheme 中的值
+*/
+func (sm *StateMachine) PlantUMLTheme(theme PlantUMLTheme) *StateMachine {
+	sm.mutable()
+	sm.sg.theme = theme
+	return sm
+}
+
+/**
+设置图的布局方向：LR 从左到右，TB 从上到下，默认使用 PlantUML 的纵向布局
+*/
+func (sm *StateMachine) Direction(direction string) *StateMachine {
+	sm.mutable()
+	sm.sg.theme.Direction = direction
+	return sm
+}
+
+func (sg *stateGraph) plantUMLTheme() PlantUMLTheme {
+	theme := sg.theme
+	if theme.NFAColor == "" {
+		theme.NFAColor = DefaultPlantUMLTheme.NFAColor
+	}
+	if theme.TerminalColor == "" {
+		theme.TerminalColor = DefaultPlantUMLTheme.TerminalColor
+	}
+	if theme.Title == "" {
+		theme.Title = DefaultPlantUMLTheme.Title
+	}
+	if theme.Direction == "" {
+		theme.Direction = DefaultPlantUMLTheme.Direction
+	}
+	return theme
+}
+
+/**
+布局方向对应的 PlantUML 指令
+*/
+func (theme PlantUMLTheme) directive() string {
+	switch strings.ToUpper(theme.Direction) {
+	case "LR":
+		return "left to right direction"
+	case "TB":
+		return "top to bottom direction"
+	}
+	return ""
+}
+
+func (sg *stateGraph) isTerminal(state State) bool {
+	for _, end := range sg.end {
+		if end == state {
+			return true
+		}
+	}
+	return false
+}
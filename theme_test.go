@@ -0,0 +1,63 @@
+package gofsm_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestStateMachine_PlantUMLTheme(t *testing.T) {
+	tests := []struct {
+		name    string
+		theme   gofsm.PlantUMLTheme
+		want    []string
+		notWant []string
+	}{
+		{"Default", gofsm.PlantUMLTheme{},
+			[]string{"BackgroundColor<<NFA>> Red", `<b>[order]</b> State Graph" as rootGraph`},
+			[]string{"direction", "<<Terminal>>"}},
+		{"Custom", gofsm.PlantUMLTheme{NFAColor: "#FFAA00", TerminalColor: "LightGreen", Title: "订单流程", Direction: "LR"},
+			[]string{"@startuml\n\tleft to right direction", "BackgroundColor<<NFA>> #FFAA00", "BackgroundColor<<Terminal>> LightGreen",
+				`<b>[order]</b> 订单流程" as rootGraph`, `state "sent" as sent <<Terminal>>`},
+			[]string{"State Graph", `state "paid" as paid <<Terminal>>`}},
+		{"Top To Bottom", gofsm.PlantUMLTheme{Direction: "tb"}, []string{"top to bottom direction"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := plantUMLScript(t, newOrderMachine().PlantUMLTheme(tt.theme))
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("PlantUML script = %v, want contains %q", got, want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("PlantUML script = %v, want not contains %q", got, notWant)
+				}
+			}
+		})
+	}
+}
+
+func TestStateMachine_Direction(t *testing.T) {
+	tests := []struct {
+		name      string
+		direction string
+		want      string
+	}{
+		{"Default", "", "@startuml\n\tskinparam state"},
+		{"Left To Right", "LR", "@startuml\n\tleft to right direction\n\tskinparam state"},
+		{"Top To Bottom", "TB", "@startuml\n\ttop to bottom direction\n\tskinparam state"},
+		{"Unknown", "XY", "@startuml\n\tskinparam state"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newOrderMachine().PlantUMLTheme(gofsm.PlantUMLTheme{NFAColor: "Blue"}).Direction(tt.direction)
+			got := plantUMLScript(t, sm)
+			if !strings.Contains(got, tt.want) || !strings.Contains(got, "BackgroundColor<<NFA>> Blue") {
+				t.Errorf("PlantUML script = %v, want contains %q", got, tt.want)
+			}
+		})
+	}
+}
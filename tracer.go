@@ -0,0 +1,53 @@
+package gofsm
+
+import (
+	"context"
+	"fmt"
+)
+
+/**
+链路追踪，每次触发创建一个名为 fsm.<name>.<event> 的 span
+StartSpan 返回的 context 向下传递给中间件、Guard、Action，触发结束时调用返回的函数
+*/
+type Tracer interface {
+	StartSpan(ctx context.Context, name string) (context.Context, func(err error))
+}
+
+/**
+Tracer 可选实现的接口，结束 span 之前设置属性，ctx 是 StartSpan 返回的 context
+	- fsm.from   开始状态
+	- fsm.event  事件
+	- fsm.to     目标状态，失败时为空
+	- fsm.result ok 或 error
+*/
+type SpanAttributer interface {
+	SetAttributes(ctx context.Context, attrs map[string]string)
+}
+
+/**
+设置链路追踪，nil 表示不追踪
+*/
+func (sm *StateMachine) Tracer(t Tracer) *StateMachine {
+	sm.mutable()
+	sm.tracer = t
+	return sm
+}
+
+func (sm *StateMachine) traced(ctx context.Context, from State, event Event,
+	fn func(ctx context.Context) (TriggerResult, error)) (TriggerResult, error) {
+	ctx, end := sm.tracer.StartSpan(ctx, fmt.Sprintf("fsm.%s.%s", sm.sg.name, event))
+	result, err := fn(ctx)
+	if attributer, ok := sm.tracer.(SpanAttributer); ok {
+		attrs := map[string]string{"fsm.from": string(from), "fsm.event": string(event), "fsm.result": "ok"}
+		if err != nil {
+			attrs["fsm.result"] = "error"
+		} else {
+			attrs["fsm.to"] = string(result.State)
+		}
+		attributer.SetAttributes(ctx, attrs)
+	}
+	if end != nil {
+		end(err)
+	}
+	return result, err
+}
@@ -0,0 +1,80 @@
+package gofsm_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+type spanKey struct{}
+
+type span struct {
+	name  string
+	attrs map[string]string
+	err   error
+	ended bool
+}
+
+type recordTracer struct {
+	spans []*span
+}
+
+func (t *recordTracer) StartSpan(ctx context.Context, name string) (context.Context, func(err error)) {
+	s := &span{name: name}
+	t.spans = append(t.spans, s)
+	return context.WithValue(ctx, spanKey{}, s), func(err error) {
+		s.err, s.ended = err, true
+	}
+}
+
+func (t *recordTracer) SetAttributes(ctx context.Context, attrs map[string]string) {
+	ctx.Value(spanKey{}).(*span).attrs = attrs
+}
+
+func TestStateMachine_Tracer(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    gofsm.State
+		event   gofsm.Event
+		span    string
+		attrs   map[string]string
+		wantErr bool
+	}{
+		{"OK", "new", "pay", "fsm.order.pay",
+			map[string]string{"fsm.from": "new", "fsm.event": "pay", "fsm.to": "paid", "fsm.result": "ok"}, false},
+		{"Error", "new", "send", "fsm.order.send",
+			map[string]string{"fsm.from": "new", "fsm.event": "send", "fsm.result": "error"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := &recordTracer{}
+			var inSpan bool
+			sm := newOrderMachine().Tracer(tracer).Use(func(next gofsm.TriggerFunc) gofsm.TriggerFunc {
+				return func(ctx context.Context, from gofsm.State, event gofsm.Event) (gofsm.State, error) {
+					inSpan = ctx.Value(spanKey{}) != nil
+					return next(ctx, from, event)
+				}
+			})
+			_, err := sm.Trigger(context.TODO(), tt.from, tt.event)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("StateMachine.Trigger() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(tracer.spans) != 1 {
+				t.Fatalf("spans = %d, want 1", len(tracer.spans))
+			}
+			s := tracer.spans[0]
+			if s.name != tt.span || !s.ended || s.err != err || !inSpan {
+				t.Errorf("span = %+v, inSpan %v, want %s ended with %v", s, inSpan, tt.span, err)
+			}
+			if !reflect.DeepEqual(s.attrs, tt.attrs) {
+				t.Errorf("span attrs = %v, want %v", s.attrs, tt.attrs)
+			}
+		})
+	}
+
+	if _, err := newOrderMachine().Tracer(nil).Trigger(context.TODO(), "new", "pay"); err != nil {
+		t.Errorf("StateMachine.Trigger() without tracer error = %v", err)
+	}
+}
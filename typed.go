@@ -0,0 +1,166 @@
+package gofsm
+
+import (
+	"context"
+	"fmt"
+	"qiniupkg.com/x/errors.v7"
+)
+
+/**
+泛型状态机，状态和事件可以使用自定义类型（例如 iota 枚举），编译期检查类型
+内部按 fmt.Sprint 的结果转换为 State / Event，由 StateMachine 执行
+字符串形式的状态机等同于 Machine[State, Event]
+*/
+type Machine[S comparable, E comparable] struct {
+	sm     *StateMachine
+	states map[State]S
+}
+
+type TypedAction[S comparable, E comparable] func(ctx context.Context, from S, event E, to []S) (S, error)
+type TypedGuard[S comparable, E comparable] func(ctx context.Context, from S, event E) (bool, error)
+
+/**
+泛型状态转换定义，Action 为 nil 时使用 NoopAction
+*/
+type TypedTransition[S comparable, E comparable] struct {
+	From      S
+	Event     E
+	To        []S
+	Action    TypedAction[S, E]
+	Processor EventProcessor
+	Guard     TypedGuard[S, E]
+	Priority  int
+}
+
+/**
+创建泛型状态机
+*/
+func NewMachine[S comparable, E comparable](name string) *Machine[S, E] {
+	return &Machine[S, E]{sm: New(name), states: map[State]S{}}
+}
+
+func (m *Machine[S, E]) States(states map[S]string) *Machine[S, E] {
+	def := StatesDef{}
+	for state, desc := range states {
+		def[m.state(state)] = desc
+	}
+	m.sm.States(def)
+	return m
+}
+
+func (m *Machine[S, E]) Events(events map[E]string) *Machine[S, E] {
+	def := EventsDef{}
+	for event, desc := range events {
+		key := Event(fmt.Sprint(event))
+		if _, ok := def[key]; ok {
+			panic(fmt.Sprintf("事件 %v 转换后的名称 %s 重复", event, key))
+		}
+		def[key] = desc
+	}
+	m.sm.Events(def)
+	return m
+}
+
+func (m *Machine[S, E]) Start(start ...S) *Machine[S, E] {
+	m.sm.Start(m.stateList(start))
+	return m
+}
+
+func (m *Machine[S, E]) End(end ...S) *Machine[S, E] {
+	m.sm.End(m.stateList(end))
+	return m
+}
+
+func (m *Machine[S, E]) Processor(processor EventProcessor) *Machine[S, E] {
+	m.sm.Processor(processor)
+	return m
+}
+
+func (m *Machine[S, E]) ProcessorV2(processor EventProcessorV2) *Machine[S, E] {
+	m.sm.ProcessorV2(processor)
+	return m
+}
+
+func (m *Machine[S, E]) ProcessorV3(processor EventProcessorV3) *Machine[S, E] {
+	m.sm.ProcessorV3(processor)
+	return m
+}
+
+func (m *Machine[S, E]) Transitions(transitions ...TypedTransition[S, E]) *Machine[S, E] {
+	for _, t := range transitions {
+		m.sm.Transitions(m.transition(t))
+	}
+	return m
+}
+
+/**
+触发状态转换
+*/
+func (m *Machine[S, E]) Trigger(ctx context.Context, from S, event E) (S, error) {
+	to, err := m.sm.Trigger(ctx, m.key(from), Event(fmt.Sprint(event)))
+	state, ok := m.states[to]
+	if !ok && to != None && err == nil {
+		return state, errors.New(fmt.Sprintf("状态机返回了未定义的状态 %s", to))
+	}
+	return state, err
+}
+
+/**
+底层字符串形式的状态机，可以用于 Show 等操作
+*/
+func (m *Machine[S, E]) Untyped() *StateMachine {
+	return m.sm
+}
+
+func (m *Machine[S, E]) transition(t TypedTransition[S, E]) Transition {
+	event := t.Event
+	action := t.Action
+	transition := Transition{
+		From:      m.state(t.From),
+		Event:     Event(fmt.Sprint(t.Event)),
+		To:        m.stateList(t.To),
+		Action:    NoopAction,
+		Processor: t.Processor,
+		Priority:  t.Priority,
+	}
+	if action != nil {
+		transition.Action = func(ctx context.Context, from State, _ Event, to []State) (State, error) {
+			targets := make([]S, 0, len(to))
+			for _, s := range to {
+				targets = append(targets, m.states[s])
+			}
+			next, err := action(ctx, m.states[from], event, targets)
+			return m.key(next), err
+		}
+	}
+	if guard := t.Guard; guard != nil {
+		transition.Guard = func(ctx context.Context, from State, _ Event) (bool, error) {
+			return guard(ctx, m.states[from], event)
+		}
+	}
+	return transition
+}
+
+/**
+登记状态并返回对应的 State，不同状态转换后名称相同时 panic
+*/
+func (m *Machine[S, E]) state(s S) State {
+	key := m.key(s)
+	if old, ok := m.states[key]; ok && old != s {
+		panic(fmt.Sprintf("状态 %v 和 %v 转换后的名称 %s 重复", old, s, key))
+	}
+	m.states[key] = s
+	return key
+}
+
+func (m *Machine[S, E]) stateList(states []S) []State {
+	var list []State
+	for _, s := range states {
+		list = append(list, m.state(s))
+	}
+	return list
+}
+
+func (m *Machine[S, E]) key(s S) State {
+	return State(fmt.Sprint(s))
+}
@@ -0,0 +1,85 @@
+package gofsm_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/threeq/gofsm"
+)
+
+type OrderState int
+
+const (
+	OrderNew OrderState = iota
+	OrderPaid
+	OrderCanceled
+)
+
+func (s OrderState) String() string {
+	return [...]string{"New", "Paid", "Canceled"}[s]
+}
+
+type OrderEvent int
+
+const (
+	OrderPay OrderEvent = iota
+	OrderCancel
+	OrderRefund
+)
+
+func TestMachine_Trigger(t *testing.T) {
+	sm := gofsm.NewMachine[OrderState, OrderEvent]("typed").
+		States(map[OrderState]string{OrderNew: "新建", OrderPaid: "已支付", OrderCanceled: "已取消"}).
+		Events(map[OrderEvent]string{OrderPay: "支付", OrderCancel: "取消"}).
+		Start(OrderNew).
+		End(OrderCanceled).
+		Transitions(
+			gofsm.TypedTransition[OrderState, OrderEvent]{From: OrderNew, Event: OrderPay, To: []OrderState{OrderPaid}},
+			gofsm.TypedTransition[OrderState, OrderEvent]{From: OrderNew, Event: OrderCancel, To: []OrderState{OrderCanceled},
+				Action: func(ctx context.Context, from OrderState, event OrderEvent, to []OrderState) (OrderState, error) {
+					if from != OrderNew || event != OrderCancel {
+						t.Errorf("Action(%v, %v), want (New, 1)", from, event)
+					}
+					return to[0], nil
+				}},
+			gofsm.TypedTransition[OrderState, OrderEvent]{From: OrderPaid, Event: OrderCancel, To: []OrderState{OrderCanceled},
+				Guard: func(ctx context.Context, from OrderState, event OrderEvent) (bool, error) {
+					return false, nil
+				}},
+		)
+
+	tests := []struct {
+		name    string
+		from    OrderState
+		event   OrderEvent
+		want    OrderState
+		wantErr bool
+	}{
+		{"Pay", OrderNew, OrderPay, OrderPaid, false},
+		{"Cancel", OrderNew, OrderCancel, OrderCanceled, false},
+		{"Guard Reject", OrderPaid, OrderCancel, OrderNew, true},
+		{"Unknown Event", OrderNew, OrderRefund, OrderNew, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sm.Trigger(context.TODO(), tt.from, tt.event)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Machine.Trigger() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Machine.Trigger() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMachine_StringStates(t *testing.T) {
+	sm := gofsm.NewMachine[gofsm.State, gofsm.Event]("").
+		States(map[gofsm.State]string{"a": "", "b": ""}).
+		Events(map[gofsm.Event]string{"go": ""}).
+		Transitions(gofsm.TypedTransition[gofsm.State, gofsm.Event]{From: "a", Event: "go", To: []gofsm.State{"b"}})
+	if got, err := sm.Trigger(context.TODO(), "a", "go"); err != nil || got != "b" {
+		t.Errorf("Machine.Trigger() = %v, %v, want b", got, err)
+	}
+}
@@ -0,0 +1,198 @@
+package gofsm
+
+import (
+	"fmt"
+	"qiniupkg.com/x/errors.v7"
+	"sort"
+	"strings"
+)
+
+/**
+Validate 时是否把未声明为结束状态的死状态（没有出边）作为错误
+*/
+func (sm *StateMachine) CheckSinks(check bool) *StateMachine {
+	sm.mutable()
+	sm.checkSinks = check
+	return sm
+}
+
+/**
+Validate 时是否把从开始状态不可达的状态作为错误，没有开始状态时不检查
+错误状态、死状态以及 After 定时转换能到达的状态视为可达
+*/
+func (sm *StateMachine) CheckReachable(check bool) *StateMachine {
+	sm.mutable()
+	sm.checkReachable = check
+	return sm
+}
+
+/**
+Validate 发现的问题类型
+*/
+type ProblemKind string
+
+const (
+	ProblemReservedState    ProblemKind = "reserved-state"    // 使用了 Start、End 或 [*] 等保留名称
+	ProblemUndefinedState   ProblemKind = "undefined-state"   // 开始、结束、错误、死状态或转换的 From 没有在 States 中定义
+	ProblemDanglingTarget   ProblemKind = "dangling-target"   // 转换的目标状态没有在 States 中定义
+	ProblemUnknownEvent     ProblemKind = "unknown-event"     // 转换的事件没有在 Events 中定义
+	ProblemFanOut           ProblemKind = "fan-out"           // 同一个事件的目标状态数量超过 WarnFanOut 设置的阈值
+	ProblemSink             ProblemKind = "sink"              // 设置 CheckSinks 时，状态没有出边也不是结束状态
+	ProblemUnreachableState ProblemKind = "unreachable-state" // 设置 CheckReachable 时，状态从开始状态不可达
+)
+
+/**
+Validate 发现的一个问题，State、Event 是相关的状态和事件，没有时为空
+*/
+type Problem struct {
+	Kind    ProblemKind `json:"kind"`
+	State   State       `json:"state,omitempty"`
+	Event   Event       `json:"event,omitempty"`
+	Message string      `json:"message"`
+}
+
+func (p Problem) String() string {
+	return string(p.Kind) + ": " + p.Message
+}
+
+/**
+检查状态机定义
+	- 开始、结束、错误、死状态以及转换中的状态都必须在 States 中定义
+	- 转换中的事件都必须在 Events 中定义
+	- Start、End 和 [*] 是保留名称，不能作为开始、结束、错误和死状态，States 和 Transitions 定义时已经检查
+	- 设置 CheckSinks 时，不能存在死状态
+	- 设置 CheckReachable 时，所有状态都必须可以从开始状态到达
+	- 设置 WarnFanOut 时，同一个事件的目标状态数量不能超过阈值
+问题的详细信息参考 Problems
+*/
+func (sm *StateMachine) Validate() error {
+	problems := sm.Problems()
+	if len(problems) == 0 {
+		return nil
+	}
+	messages := make([]string, len(problems))
+	for i, problem := range problems {
+		messages[i] = problem.Message
+	}
+	return errors.New("状态机定义错误: " + strings.Join(messages, "; "))
+}
+
+/**
+返回 Validate 发现的所有问题，顺序与 Validate 的错误信息一致，没有问题时返回 nil
+*/
+func (sm *StateMachine) Problems() []Problem {
+	sg := sm.sg
+	var problems []Problem
+	checkState := func(state State, event Event, where string, undefined ProblemKind) {
+		if reservedState(state) {
+			problems = append(problems, Problem{Kind: ProblemReservedState, State: state, Event: event,
+				Message: fmt.Sprintf("%s %s 是保留名称，开始和结束请使用 Start、End", where, state)})
+			return
+		}
+		if _, ok := sg.states[state]; !ok {
+			problems = append(problems, Problem{Kind: undefined, State: state, Event: event,
+				Message: fmt.Sprintf("%s %s 没有定义", where, state)})
+		}
+	}
+
+	for _, state := range sg.start {
+		checkState(state, None, "开始状态", ProblemUndefinedState)
+	}
+	for _, state := range sg.end {
+		checkState(state, None, "结束状态", ProblemUndefinedState)
+	}
+	if sg.errorState != None {
+		checkState(sg.errorState, None, "错误状态", ProblemUndefinedState)
+	}
+	if sg.deadState != None {
+		checkState(sg.deadState, None, "死状态", ProblemUndefinedState)
+	}
+	sg.each(func(transfer *Transition) bool {
+		if transfer.From != Start {
+			checkState(transfer.From, transfer.Event, "转换 "+transfer.String()+" 的状态", ProblemUndefinedState)
+		}
+		for _, to := range transfer.To {
+			if to != End && to != None {
+				checkState(to, transfer.Event, "转换 "+transfer.String()+" 的目标状态", ProblemDanglingTarget)
+			}
+		}
+		if _, ok := sg.events[transfer.Event]; !ok && transfer.Event != None {
+			problems = append(problems, Problem{Kind: ProblemUnknownEvent, State: transfer.From, Event: transfer.Event,
+				Message: fmt.Sprintf("转换 %s 的事件 %s 没有定义", transfer, transfer.Event)})
+		}
+		return true
+	})
+	sm.fanOuts(func(from State, event Event, targets int) {
+		problems = append(problems, Problem{Kind: ProblemFanOut, State: from, Event: event,
+			Message: fmt.Sprintf("状态 %s 上的事件 %s 有 %d 个目标状态，超过 %d", from, event, targets, sm.fanOut)})
+	})
+	if sm.checkSinks {
+		for _, state := range sm.Sinks() {
+			problems = append(problems, Problem{Kind: ProblemSink, State: state,
+				Message: fmt.Sprintf("状态 %s 没有出边，也不是结束状态", state)})
+		}
+	}
+	if sm.checkReachable {
+		for _, state := range sg.unreachable() {
+			problems = append(problems, Problem{Kind: ProblemUnreachableState, State: state,
+				Message: fmt.Sprintf("状态 %s 从开始状态不可达", state)})
+		}
+	}
+	return problems
+}
+
+/**
+死状态：没有任何出边，也没有声明为结束状态的状态
+*/
+func (sm *StateMachine) Sinks() []State {
+	sg := sm.sg
+	terminal := map[State]bool{}
+	for _, state := range sg.end {
+		terminal[state] = true
+	}
+
+	var sinks []State
+	for state := range sg.states {
+		if !terminal[state] && len(sg.transitions[state]) == 0 {
+			sinks = append(sinks, state)
+		}
+	}
+	sort.Slice(sinks, func(i, j int) bool { return sinks[i] < sinks[j] })
+	return sinks
+}
+
+/**
+从开始状态不可达的状态，按名称排序，没有开始状态时返回 nil
+*/
+func (sg *stateGraph) unreachable() []State {
+	reachable := sg.reachableFromRoots()
+	if reachable == nil {
+		return nil
+	}
+	var states []State
+	for state := range sg.states {
+		if !reachable[state] {
+			states = append(states, state)
+		}
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i] < states[j] })
+	return states
+}
+
+/**
+状态是否是状态机中的状态，开始和结束的标记 Start、End 总是包含在内
+*/
+func (sg *stateGraph) contains(state State) bool {
+	if state == Start || state == End {
+		return true
+	}
+	_, ok := sg.states[state]
+	return ok
+}
+
+/**
+Start、End 以及图中的 [*] 不能作为状态名称
+*/
+func reservedState(state State) bool {
+	return state == Start || state == End || state == pseudoState
+}
@@ -0,0 +1,157 @@
+package gofsm_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/threeq/gofsm"
+)
+
+func TestStateMachine_Sinks(t *testing.T) {
+	tests := []struct {
+		name string
+		sm   *gofsm.StateMachine
+		want []gofsm.State
+	}{
+		{"Empty", gofsm.New(""), nil},
+		{"Order", newOrderMachine(), []gofsm.State{"imported"}},
+		{"Forgot Review", newOrderMachine().
+			States(gofsm.StatesDef{"new": "", "paid": "", "sent": "", "review": ""}).
+			Transitions(gofsm.Transition{From: "paid", Event: "pay", To: []gofsm.State{"review"}, Action: gofsm.NoopAction}),
+			[]gofsm.State{"review"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sm.Sinks(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StateMachine.Sinks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStateMachine_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		sm   *gofsm.StateMachine
+		want []string
+	}{
+		{"Empty", gofsm.New(""), nil},
+		{"Order", newOrderMachine(), nil},
+		{"Order Check Sinks", newOrderMachine().CheckSinks(true), []string{"状态 imported 没有出边"}},
+		{"Order Fan Out", newOrderMachine().WarnFanOut(1).Transitions(
+			gofsm.Transition{From: "new", Event: "pay", To: []gofsm.State{"imported"}, Action: gofsm.NoopAction},
+		), []string{"状态 new 上的事件 pay 有 2 个目标状态，超过 1"}},
+		{"Undefined", gofsm.New("").
+			States(gofsm.StatesDef{"a": ""}).
+			Events(gofsm.EventsDef{"e1": ""}).
+			Start([]gofsm.State{"s"}).
+			End([]gofsm.State{"z"}).
+			ErrorState("err").
+			Transitions(
+				gofsm.Transition{From: gofsm.Start, Event: gofsm.None, To: []gofsm.State{"a"}, Action: gofsm.NoopAction},
+				gofsm.Transition{From: "a", Event: "e1", To: []gofsm.State{"b", gofsm.End}, Action: gofsm.NoopAction},
+				gofsm.Transition{From: "c", Event: "e2", To: []gofsm.State{"a"}, Action: gofsm.NoopAction},
+			),
+			[]string{"开始状态 s 没有定义", "结束状态 z 没有定义", "错误状态 err 没有定义",
+				"的目标状态 b 没有定义", "的状态 c 没有定义", "的事件 e2 没有定义"}},
+		{"Reserved", gofsm.New("").
+			States(gofsm.StatesDef{"a": ""}).
+			Events(gofsm.EventsDef{"e1": ""}).
+			Start([]gofsm.State{gofsm.Start}).
+			End([]gofsm.State{"[*]"}).
+			ErrorState(gofsm.End).
+			Transitions(
+				gofsm.Transition{From: gofsm.Start, Event: gofsm.None, To: []gofsm.State{"a"}, Action: gofsm.NoopAction},
+				gofsm.Transition{From: "a", Event: "e1", To: []gofsm.State{gofsm.End}, Action: gofsm.NoopAction},
+			),
+			[]string{"开始状态 " + gofsm.Start + " 是保留名称", "结束状态 [*] 是保留名称", "错误状态 " + gofsm.End + " 是保留名称"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.sm.Validate()
+			if (err != nil) != (len(tt.want) > 0) {
+				t.Fatalf("StateMachine.Validate() error = %v, want %v", err, tt.want)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("StateMachine.Validate() error = %v, want contains %v", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestStateMachine_Problems(t *testing.T) {
+	tests := []struct {
+		name string
+		sm   *gofsm.StateMachine
+		want []gofsm.Problem
+	}{
+		{"Order", newOrderMachine(), nil},
+		{"Order Check Sinks", newOrderMachine().CheckSinks(true), []gofsm.Problem{
+			{Kind: gofsm.ProblemSink, State: "imported", Message: "状态 imported 没有出边，也不是结束状态"},
+		}},
+		{"Undefined", gofsm.New("").
+			States(gofsm.StatesDef{"a": ""}).
+			Events(gofsm.EventsDef{"e1": ""}).
+			Start([]gofsm.State{gofsm.Start, "s"}).
+			Transitions(
+				gofsm.Transition{From: "a", Event: "e1", To: []gofsm.State{"b"}, Action: gofsm.NoopAction},
+				gofsm.Transition{From: "c", Event: "e2", To: []gofsm.State{"a"}, Action: gofsm.NoopAction},
+			),
+			[]gofsm.Problem{
+				{Kind: gofsm.ProblemReservedState, State: gofsm.Start, Message: "开始状态 " + gofsm.Start + " 是保留名称，开始和结束请使用 Start、End"},
+				{Kind: gofsm.ProblemUndefinedState, State: "s", Message: "开始状态 s 没有定义"},
+				{Kind: gofsm.ProblemDanglingTarget, State: "b", Event: "e1", Message: "转换 a --> [b]: e1 的目标状态 b 没有定义"},
+				{Kind: gofsm.ProblemUndefinedState, State: "c", Event: "e2", Message: "转换 c --> [a]: e2 的状态 c 没有定义"},
+				{Kind: gofsm.ProblemUnknownEvent, State: "c", Event: "e2", Message: "转换 c --> [a]: e2 的事件 e2 没有定义"},
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.sm.Problems()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StateMachine.Problems() = %v, want %v", got, tt.want)
+			}
+			if err := tt.sm.Validate(); (err != nil) != (len(tt.want) > 0) {
+				t.Errorf("StateMachine.Validate() error = %v, want %d problems", err, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestStateMachine_CheckReachable(t *testing.T) {
+	tests := []struct {
+		name string
+		sm   *gofsm.StateMachine
+		want []gofsm.Problem
+	}{
+		{"Order Unchecked", newOrderMachine(), nil},
+		{"Order", newOrderMachine().CheckReachable(true), []gofsm.Problem{
+			{Kind: gofsm.ProblemUnreachableState, State: "imported", Message: "状态 imported 从开始状态不可达"},
+		}},
+		{"No Start", gofsm.New("").
+			States(gofsm.StatesDef{"a": "", "b": ""}).
+			Events(gofsm.EventsDef{"e": ""}).
+			CheckReachable(true), nil},
+		{"Implicit Entries", newOrderMachine().
+			States(gofsm.StatesDef{"new": "", "paid": "", "sent": "", "imported": "", "failed": "", "expired": "", "archived": ""}).
+			ErrorState("failed").
+			AutoDeadState("imported").
+			After("paid", time.Hour, "expired", nil).
+			After("expired", time.Hour, "archived", nil).
+			CheckReachable(true), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sm.Problems(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StateMachine.Problems() = %v, want %v", got, tt.want)
+			}
+			if err := tt.sm.Validate(); (err != nil) != (len(tt.want) > 0) {
+				t.Errorf("StateMachine.Validate() error = %v, want %d problems", err, len(tt.want))
+			}
+		})
+	}
+}